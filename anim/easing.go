@@ -0,0 +1,143 @@
+// Package anim has keyframed tracks, easing curves and scene sequencing for animations.
+// Tracks are sampled by percent inside a render.FrameFunc.
+package anim
+
+import "math"
+
+// Easing maps a linear t from 0 to 1 onto an eased t.
+// Most easings return 0 at 0 and 1 at 1, but elastic and back style curves may overshoot in between.
+type Easing func(t float64) float64
+
+// Linear moves at a constant rate.
+func Linear(t float64) float64 {
+	return t
+}
+
+// Hold keeps the starting value until the next key is reached.
+func Hold(t float64) float64 {
+	if t >= 1 {
+		return 1
+	}
+	return 0
+}
+
+// InQuad starts slowly and accelerates.
+func InQuad(t float64) float64 {
+	return t * t
+}
+
+// OutQuad starts quickly and decelerates.
+func OutQuad(t float64) float64 {
+	return 1 - (1-t)*(1-t)
+}
+
+// InOutQuad accelerates to the middle then decelerates.
+func InOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - 2*(1-t)*(1-t)
+}
+
+// InCubic starts slowly and accelerates, more sharply than InQuad.
+func InCubic(t float64) float64 {
+	return t * t * t
+}
+
+// OutCubic starts quickly and decelerates, more sharply than OutQuad.
+func OutCubic(t float64) float64 {
+	u := 1 - t
+	return 1 - u*u*u
+}
+
+// InOutCubic accelerates to the middle then decelerates.
+func InOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	u := 1 - t
+	return 1 - 4*u*u*u
+}
+
+// InElastic winds up with a growing wobble before moving.
+func InElastic(t float64) float64 {
+	return 1 - OutElastic(1-t)
+}
+
+// OutElastic overshoots and settles with a shrinking wobble, like a spring.
+func OutElastic(t float64) float64 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+	return math.Pow(2, -10*t)*math.Sin((t*10-0.75)*2*math.Pi/3) + 1
+}
+
+// InOutElastic wobbles in and out.
+func InOutElastic(t float64) float64 {
+	if t < 0.5 {
+		return InElastic(t*2) / 2
+	}
+	return 0.5 + OutElastic(t*2-1)/2
+}
+
+// InBounce bounces with growing height before moving.
+func InBounce(t float64) float64 {
+	return 1 - OutBounce(1-t)
+}
+
+// OutBounce falls to the end value and bounces to a stop.
+func OutBounce(t float64) float64 {
+	const n, d = 7.5625, 2.75
+	switch {
+	case t < 1/d:
+		return n * t * t
+	case t < 2/d:
+		t -= 1.5 / d
+		return n*t*t + 0.75
+	case t < 2.5/d:
+		t -= 2.25 / d
+		return n*t*t + 0.9375
+	}
+	t -= 2.625 / d
+	return n*t*t + 0.984375
+}
+
+// InOutBounce bounces in and out.
+func InOutBounce(t float64) float64 {
+	if t < 0.5 {
+		return InBounce(t*2) / 2
+	}
+	return 0.5 + OutBounce(t*2-1)/2
+}
+
+// CubicBezier creates an easing from a css style cubic bezier curve through (0, 0), (x1, y1), (x2, y2) and (1, 1).
+// x1 and x2 are clamped to 0 to 1 so the curve always moves forward in time.
+func CubicBezier(x1, y1, x2, y2 float64) Easing {
+	x1 = math.Max(0, math.Min(1, x1))
+	x2 = math.Max(0, math.Min(1, x2))
+	bezier := func(s, p1, p2 float64) float64 {
+		u := 1 - s
+		return 3*u*u*s*p1 + 3*u*s*s*p2 + s*s*s
+	}
+	return func(t float64) float64 {
+		if t <= 0 || t >= 1 {
+			return t
+		}
+		// find the curve parameter whose x is t. x rises with s, so bisection always converges.
+		lo, hi := 0.0, 1.0
+		s := t
+		for i := 0; i < 30; i++ {
+			x := bezier(s, x1, x2)
+			if math.Abs(x-t) < 1e-7 {
+				break
+			}
+			if x < t {
+				lo = s
+			} else {
+				hi = s
+			}
+			s = (lo + hi) / 2
+		}
+		return bezier(s, y1, y2)
+	}
+}
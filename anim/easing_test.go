@@ -0,0 +1,41 @@
+package anim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEasingEndpoints(t *testing.T) {
+	easings := map[string]Easing{
+		"Linear": Linear, "Hold": Hold,
+		"InQuad": InQuad, "OutQuad": OutQuad, "InOutQuad": InOutQuad,
+		"InCubic": InCubic, "OutCubic": OutCubic, "InOutCubic": InOutCubic,
+		"InElastic": InElastic, "OutElastic": OutElastic, "InOutElastic": InOutElastic,
+		"InBounce": InBounce, "OutBounce": OutBounce, "InOutBounce": InOutBounce,
+		"CubicBezier": CubicBezier(0.25, 0.1, 0.25, 1),
+	}
+	for name, ease := range easings {
+		if v := ease(0); math.Abs(v) > 1e-9 {
+			t.Errorf("%s(0) = %v, want 0", name, v)
+		}
+		if v := ease(1); math.Abs(v-1) > 1e-9 {
+			t.Errorf("%s(1) = %v, want 1", name, v)
+		}
+	}
+}
+
+func TestCubicBezier(t *testing.T) {
+	linear := CubicBezier(0, 0, 1, 1)
+	for _, x := range []float64{0.1, 0.3, 0.5, 0.9} {
+		if v := linear(x); math.Abs(v-x) > 1e-5 {
+			t.Errorf("linear bezier at %v is %v", x, v)
+		}
+	}
+	// an ease in curve stays below the line and an ease out curve above it.
+	if v := CubicBezier(0.42, 0, 1, 1)(0.5); v >= 0.5 {
+		t.Errorf("ease in at 0.5 is %v, want less than 0.5", v)
+	}
+	if v := CubicBezier(0, 0, 0.58, 1)(0.5); v <= 0.5 {
+		t.Errorf("ease out at 0.5 is %v, want more than 0.5", v)
+	}
+}
@@ -0,0 +1,54 @@
+package anim
+
+import (
+	"github.com/bit101/blgg"
+	"github.com/bit101/blgg/render"
+)
+
+// Scene is one part of a sequence.
+type Scene struct {
+	// Length is the scene's share of the sequence, in any unit, such as seconds or frames.
+	// 0 counts as 1.
+	Length float64
+	// Frame draws the scene. It receives the percent through the scene, not through the whole sequence.
+	Frame render.FrameFunc
+}
+
+// Sequence joins scenes back to back into a single FrameFunc, so they render in one Frames, GIF or video call.
+func Sequence(scenes ...Scene) render.FrameFunc {
+	total := 0.0
+	for _, scene := range scenes {
+		total += scene.length()
+	}
+	return func(context *blgg.Context, width, height, percent float64) {
+		if len(scenes) == 0 {
+			return
+		}
+		i, local := locate(scenes, total, percent)
+		scenes[i].Frame(context, width, height, local)
+	}
+}
+
+// locate finds the scene playing at percent and the percent through that scene.
+func locate(scenes []Scene, total, percent float64) (int, float64) {
+	start := 0.0
+	for i, scene := range scenes {
+		end := start + scene.length()/total
+		if percent < end || i == len(scenes)-1 {
+			local := (percent - start) / (end - start)
+			if local > 1 {
+				local = 1
+			}
+			return i, local
+		}
+		start = end
+	}
+	return 0, 0
+}
+
+func (s Scene) length() float64 {
+	if s.Length <= 0 {
+		return 1
+	}
+	return s.Length
+}
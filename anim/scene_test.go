@@ -0,0 +1,41 @@
+package anim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestSequence(t *testing.T) {
+	var scene int
+	var local float64
+	frame := func(i int) func(*blgg.Context, float64, float64, float64) {
+		return func(context *blgg.Context, width, height, percent float64) {
+			scene, local = i, percent
+		}
+	}
+	// the second scene is three times as long, and a zero length counts as one.
+	sequence := Sequence(Scene{Length: 0, Frame: frame(0)}, Scene{Length: 3, Frame: frame(1)})
+	tests := []struct {
+		percent float64
+		scene   int
+		local   float64
+	}{
+		{0, 0, 0},
+		{0.125, 0, 0.5},
+		{0.2499, 0, 0.9996},
+		{0.25, 1, 0},
+		{0.625, 1, 0.5},
+		{1, 1, 1},
+	}
+	for _, test := range tests {
+		sequence(nil, 100, 100, test.percent)
+		if scene != test.scene || math.Abs(local-test.local) > 1e-9 {
+			t.Errorf("at %v scene %d plays at %v, want scene %d at %v", test.percent, scene, local, test.scene, test.local)
+		}
+	}
+
+	// an empty sequence draws nothing.
+	Sequence()(nil, 100, 100, 0.5)
+}
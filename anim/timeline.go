@@ -0,0 +1,109 @@
+package anim
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// Key is a keyframe: the value a track has at a given percent.
+type Key struct {
+	// At is the percent, from 0 to 1, that the key sits at.
+	At float64
+	// Value is the track's value at the key.
+	Value float64
+	// Ease shapes the move from this key to the next. nil is Linear, Hold keeps the value until the next key.
+	Ease Easing
+}
+
+// Track is a named value animated by keyframes.
+// Before the first key it has the first key's value and after the last key the last key's value.
+type Track struct {
+	Name string
+	Keys []Key
+	// Loops plays the keys this many times over the timeline. 0 or 1 plays them once.
+	Loops int
+	// PingPong plays every second loop backwards, so a looping track has no jump.
+	PingPong bool
+}
+
+// Value returns the track's value at percent.
+func (t *Track) Value(percent float64) float64 {
+	if len(t.Keys) == 0 {
+		return 0
+	}
+	percent = t.loop(percent)
+	first, last := t.Keys[0], t.Keys[len(t.Keys)-1]
+	if percent <= first.At {
+		return first.Value
+	}
+	if percent >= last.At {
+		return last.Value
+	}
+	// the first key after percent ends the segment percent is in.
+	i := sort.Search(len(t.Keys), func(i int) bool { return t.Keys[i].At > percent })
+	from, to := t.Keys[i-1], t.Keys[i]
+	ease := from.Ease
+	if ease == nil {
+		ease = Linear
+	}
+	return blmath.Lerp(ease((percent-from.At)/(to.At-from.At)), from.Value, to.Value)
+}
+
+// loop maps the timeline's percent onto the percent within the current loop.
+func (t *Track) loop(percent float64) float64 {
+	if t.Loops <= 1 {
+		return percent
+	}
+	p := percent * float64(t.Loops)
+	n := math.Floor(p)
+	local := p - n
+	if n >= float64(t.Loops) {
+		// the very end of the timeline is the end of the last loop.
+		n, local = float64(t.Loops-1), 1
+	}
+	if t.PingPong && int(n)%2 == 1 {
+		return 1 - local
+	}
+	return local
+}
+
+// Timeline holds a set of named tracks.
+type Timeline struct {
+	tracks map[string]*Track
+}
+
+// NewTimeline creates an empty timeline.
+func NewTimeline() *Timeline {
+	return &Timeline{tracks: map[string]*Track{}}
+}
+
+// Track adds a track with the given keys, replacing any track with the same name.
+// The keys are sorted by their At value. The track is returned so Loops and PingPong can be set.
+func (t *Timeline) Track(name string, keys ...Key) *Track {
+	sorted := append([]Key(nil), keys...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At < sorted[j].At })
+	track := &Track{Name: name, Keys: sorted}
+	t.tracks[name] = track
+	return track
+}
+
+// Value returns the value of the named track at percent. It panics if there is no such track,
+// as a misspelt name would otherwise silently animate nothing.
+func (t *Timeline) Value(name string, percent float64) float64 {
+	track, ok := t.tracks[name]
+	if !ok {
+		panic("anim: unknown track " + name)
+	}
+	return track.Value(percent)
+}
+
+// Values returns the value of every track at percent, by name.
+func (t *Timeline) Values(percent float64) map[string]float64 {
+	values := make(map[string]float64, len(t.tracks))
+	for name, track := range t.tracks {
+		values[name] = track.Value(percent)
+	}
+	return values
+}
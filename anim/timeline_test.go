@@ -0,0 +1,66 @@
+package anim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrackValue(t *testing.T) {
+	timeline := NewTimeline()
+	// keys are given out of order and sorted by the timeline.
+	timeline.Track("x", Key{At: 0.8, Value: 50}, Key{At: 0.2, Value: 10}, Key{At: 0.6, Value: 30, Ease: InQuad})
+	tests := []struct {
+		percent, want float64
+	}{
+		{0, 10},
+		{0.2, 10},
+		{0.4, 20},
+		{0.6, 30},
+		{0.7, 35},
+		{0.8, 50},
+		{1, 50},
+	}
+	for _, test := range tests {
+		if v := timeline.Value("x", test.percent); math.Abs(v-test.want) > 1e-9 {
+			t.Errorf("x at %v is %v, want %v", test.percent, v, test.want)
+		}
+	}
+}
+
+func TestTrackHold(t *testing.T) {
+	timeline := NewTimeline()
+	timeline.Track("step", Key{At: 0, Value: 0, Ease: Hold}, Key{At: 0.5, Value: 1, Ease: Hold}, Key{At: 1, Value: 2})
+	for _, test := range []struct{ percent, want float64 }{{0.49, 0}, {0.5, 1}, {0.99, 1}, {1, 2}} {
+		if v := timeline.Value("step", test.percent); v != test.want {
+			t.Errorf("step at %v is %v, want %v", test.percent, v, test.want)
+		}
+	}
+}
+
+func TestTrackLoops(t *testing.T) {
+	timeline := NewTimeline()
+	track := timeline.Track("x", Key{At: 0, Value: 0}, Key{At: 1, Value: 10})
+	track.Loops = 2
+	track.PingPong = true
+	for _, test := range []struct{ percent, want float64 }{{0, 0}, {0.25, 5}, {0.5, 10}, {0.6, 8}, {0.75, 5}, {1, 0}} {
+		if v := track.Value(test.percent); math.Abs(v-test.want) > 1e-9 {
+			t.Errorf("ping pong at %v is %v, want %v", test.percent, v, test.want)
+		}
+	}
+	track.PingPong = false
+	if v := track.Value(0.75); math.Abs(v-5) > 1e-9 {
+		t.Errorf("loop at 0.75 is %v, want 5", v)
+	}
+	if v := track.Value(1); v != 10 {
+		t.Errorf("loop at 1 is %v, want 10", v)
+	}
+}
+
+func TestTimelineUnknownTrack(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for an unknown track")
+		}
+	}()
+	NewTimeline().Value("missing", 0)
+}
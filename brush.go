@@ -0,0 +1,223 @@
+// Package blgg is the main package for this module.
+package blgg
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// brushTolerance is how closely paths are flattened before outlining, in the path's units.
+const brushTolerance = 0.05
+
+// brushStep is the longest distance between the points where a brush stroke's width is measured.
+const brushStep = 1.0
+
+// brushMiter limits how far the outline reaches out at a sharp corner, as a multiple of half the width.
+const brushMiter = 4.0
+
+// WidthFunc gives the width of a stroke at t, from 0 at the start of each subpath to 1 at its end.
+// Widths are in the same units as the path, so they scale with the context's transform.
+type WidthFunc func(t float64) float64
+
+// ConstantWidth returns a width that is the same all along the stroke.
+func ConstantWidth(width float64) WidthFunc {
+	return func(t float64) float64 {
+		return width
+	}
+}
+
+// Taper returns a width that swells from nothing over the first start fraction of the stroke
+// and shrinks back to nothing over the last end fraction, like a brush touching down and lifting off.
+func Taper(width, start, end float64) WidthFunc {
+	return func(t float64) float64 {
+		w := width
+		if start > 0 && t < start {
+			w *= math.Sin(t / start * math.Pi / 2)
+		}
+		if end > 0 && t > 1-end {
+			w *= math.Sin((1 - t) / end * math.Pi / 2)
+		}
+		return w
+	}
+}
+
+// Pressure returns a width scaled by pressures spaced evenly along the stroke, usually from 0 to 1,
+// such as those recorded from a pen. The pressure eases smoothly from each value to the next.
+func Pressure(width float64, pressures ...float64) WidthFunc {
+	return func(t float64) float64 {
+		if len(pressures) == 0 {
+			return width
+		}
+		if len(pressures) == 1 {
+			return width * pressures[0]
+		}
+		pos := math.Max(0, math.Min(1, t)) * float64(len(pressures)-1)
+		i := math.Min(math.Floor(pos), float64(len(pressures)-2))
+		f := pos - i
+		f = f * f * (3 - 2*f)
+		p0, p1 := pressures[int(i)], pressures[int(i)+1]
+		return width * (p0 + (p1-p0)*f)
+	}
+}
+
+// NoiseWidth returns a width that wobbles around the base width by up to amount, as a fraction of it.
+// Frequency is the number of wobbles along the stroke. The wobble comes from the context's random
+// numbers, so it follows the context's seed.
+func (c *Context) NoiseWidth(base WidthFunc, amount, frequency float64) WidthFunc {
+	values := make([]float64, int(math.Ceil(math.Max(0, frequency)))+2)
+	for i := range values {
+		values[i] = c.RandomRange(-1, 1)
+	}
+	return func(t float64) float64 {
+		pos := math.Max(0, math.Min(1, t)) * math.Max(0, frequency)
+		i := math.Floor(pos)
+		f := (1 - math.Cos((pos-i)*math.Pi)) / 2
+		v0, v1 := values[int(i)], values[int(i)+1]
+		return base(t) * (1 + amount*(v0+(v1-v0)*f))
+	}
+}
+
+// Outline returns the outline of the path stroked with a width that changes along it, as a path to fill
+// with the winding rule. Open subpaths have round ends, and closed subpaths give an outer and inner edge.
+func (p Path) Outline(width WidthFunc) Path {
+	var outline Path
+	for _, points := range p.Flatten(brushTolerance) {
+		line := newPolyline(resample(points))
+		if len(line.points) < 2 {
+			continue
+		}
+		outline.addOutline(line, width)
+	}
+	return outline
+}
+
+// addOutline adds the edges of one stroked polyline.
+func (p *Path) addOutline(line polyline, width WidthFunc) {
+	closed := line.closed() && len(line.points) > 2
+	points := line.points
+	n := len(points)
+	if closed {
+		// the last point is the first, which is outlined once.
+		n--
+	}
+	normal := func(a, b geom.Point) geom.Point {
+		d := math.Hypot(b.X-a.X, b.Y-a.Y)
+		return geom.Point{X: -(b.Y - a.Y) / d, Y: (b.X - a.X) / d}
+	}
+
+	left := make([]geom.Point, n)
+	right := make([]geom.Point, n)
+	half := make([]float64, n)
+	for i := 0; i < n; i++ {
+		var before, after geom.Point
+		hasBefore, hasAfter := i > 0 || closed, i < n-1 || closed
+		if hasBefore {
+			before = normal(points[(i-1+n)%n], points[i])
+		}
+		if hasAfter {
+			after = normal(points[i], points[(i+1)%len(points)])
+		}
+		// at a corner the edge is pushed out along the bisector, so both sides keep their width.
+		m := after
+		switch {
+		case !hasAfter:
+			m = before
+		case hasBefore:
+			sum := geom.Point{X: before.X + after.X, Y: before.Y + after.Y}
+			length := math.Hypot(sum.X, sum.Y)
+			if length > 1e-9 {
+				m = geom.Point{X: sum.X / length, Y: sum.Y / length}
+				scale := 1 / math.Max(1/brushMiter, m.X*after.X+m.Y*after.Y)
+				m = geom.Point{X: m.X * scale, Y: m.Y * scale}
+			}
+		}
+		half[i] = math.Max(0, width(line.dists[i]/line.length())) / 2
+		left[i] = geom.Point{X: points[i].X + m.X*half[i], Y: points[i].Y + m.Y*half[i]}
+		right[i] = geom.Point{X: points[i].X - m.X*half[i], Y: points[i].Y - m.Y*half[i]}
+	}
+
+	if closed {
+		p.MoveTo(left[0].X, left[0].Y)
+		for _, pt := range left[1:] {
+			p.LineTo(pt.X, pt.Y)
+		}
+		p.ClosePath()
+		p.MoveTo(right[n-1].X, right[n-1].Y)
+		for i := n - 2; i >= 0; i-- {
+			p.LineTo(right[i].X, right[i].Y)
+		}
+		p.ClosePath()
+		return
+	}
+
+	p.MoveTo(left[0].X, left[0].Y)
+	for _, pt := range left[1:] {
+		p.LineTo(pt.X, pt.Y)
+	}
+	// round ends turn from the left edge to the right edge, through the direction of travel.
+	end := normal(points[n-2], points[n-1])
+	if half[n-1] > 0 {
+		a := math.Atan2(end.Y, end.X)
+		p.DrawArc(points[n-1].X, points[n-1].Y, half[n-1], a, a-math.Pi)
+	}
+	for i := n - 1; i >= 0; i-- {
+		p.LineTo(right[i].X, right[i].Y)
+	}
+	start := normal(points[0], points[1])
+	if half[0] > 0 {
+		a := math.Atan2(start.Y, start.X)
+		p.DrawArc(points[0].X, points[0].Y, half[0], a-math.Pi, a-2*math.Pi)
+	}
+	p.ClosePath()
+}
+
+// resample drops repeated points and adds points so that none are more than brushStep apart.
+func resample(points []geom.Point) []geom.Point {
+	out := []geom.Point{points[0]}
+	for _, pt := range points[1:] {
+		last := out[len(out)-1]
+		d := math.Hypot(pt.X-last.X, pt.Y-last.Y)
+		if d < 1e-9 {
+			// the later point is kept, as it may be the exact start of a closed line.
+			if len(out) > 1 {
+				out[len(out)-1] = pt
+			}
+			continue
+		}
+		steps := math.Ceil(d / brushStep)
+		for i := 1.0; i < steps; i++ {
+			out = append(out, geom.Point{X: last.X + (pt.X-last.X)*i/steps, Y: last.Y + (pt.Y-last.Y)*i/steps})
+		}
+		out = append(out, pt)
+	}
+	return out
+}
+
+////////////////////
+// BRUSH STROKES
+////////////////////
+
+// BrushStroke fills the outline of a path stroked with a width that changes along it. See Path.Outline.
+func (c *Context) BrushStroke(path Path, width WidthFunc) {
+	c.Push()
+	c.SetFillRuleWinding()
+	path.Outline(width).Draw(c)
+	c.Fill()
+	c.Pop()
+}
+
+// BrushPath draws a series of lines through a set of points with a brush stroke.
+func (c *Context) BrushPath(points []*geom.Point, close bool, width WidthFunc) {
+	c.BrushStroke(PolylinePath(points, close), width)
+}
+
+// BrushMultiCurve draws a multi curve with a brush stroke.
+func (c *Context) BrushMultiCurve(points []*geom.Point, width WidthFunc) {
+	c.BrushStroke(MultiCurvePath(points), width)
+}
+
+// BrushFractalLine draws a fractal line between two points with a brush stroke.
+func (c *Context) BrushFractalLine(x1, y1, x2, y2, roughness float64, iterations int, width WidthFunc) {
+	c.BrushStroke(c.FractalLinePath(x1, y1, x2, y2, roughness, iterations), width)
+}
@@ -0,0 +1,116 @@
+package blgg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// area returns the signed area inside a closed polyline, positive when it turns clockwise on screen.
+func area(points []geom.Point) float64 {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += points[i-1].X*points[i].Y - points[i].X*points[i-1].Y
+	}
+	return total / 2
+}
+
+func checkBounds(t *testing.T, name string, p Path, x, y, w, h float64) {
+	t.Helper()
+	const within = 0.01
+	bx, by, bw, bh := p.Bounds()
+	if math.Abs(bx-x) > within || math.Abs(by-y) > within || math.Abs(bw-w) > within || math.Abs(bh-h) > within {
+		t.Errorf("%s: bounds %v %v %v %v, want %v %v %v %v", name, bx, by, bw, bh, x, y, w, h)
+	}
+}
+
+func TestOutlineOpen(t *testing.T) {
+	var line Path
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+	outline := line.Outline(ConstantWidth(2))
+	rings := outline.Flatten(0.01)
+	if len(rings) != 1 {
+		t.Fatalf("%d rings, want 1", len(rings))
+	}
+	// two sides and two round ends.
+	if length, want := outline.Length(), 20+2*math.Pi; math.Abs(length-want) > 0.01 {
+		t.Errorf("outline is %v long, want %v", length, want)
+	}
+	checkBounds(t, "open", outline, -1, -1, 12, 2)
+	for _, pt := range rings[0] {
+		d := math.Abs(pt.Y)
+		if pt.X < 0 || pt.X > 10 {
+			d = math.Hypot(pt.X-math.Max(0, math.Min(10, pt.X)), pt.Y)
+		}
+		if math.Abs(d-1) > 0.01 {
+			t.Errorf("outline point %v is %v from the line, want 1", pt, d)
+		}
+	}
+
+	// a tapered stroke comes to a point at each end instead of a round cap.
+	tapered := line.Outline(Taper(2, 0.5, 0.5))
+	if start := tapered.Segments[0].Points[0]; !near(start, geom.Point{}) {
+		t.Errorf("tapered outline starts at %v, want the start of the line", start)
+	}
+	checkBounds(t, "tapered", tapered, 0, -1, 10, 2)
+}
+
+func TestOutlineClosed(t *testing.T) {
+	outline := RectanglePath(0, 0, 10, 10).Outline(ConstantWidth(2))
+	rings := outline.Flatten(0.01)
+	if len(rings) != 2 {
+		t.Fatalf("%d rings, want an outer and an inner edge", len(rings))
+	}
+	// the corners are mitered, and the edges wind in opposite directions so the middle is left empty.
+	var outer, inner Path
+	for _, pt := range rings[0] {
+		outer.LineTo(pt.X, pt.Y)
+	}
+	for _, pt := range rings[1] {
+		inner.LineTo(pt.X, pt.Y)
+	}
+	if _, _, w, _ := outer.Bounds(); w < 10 {
+		outer, inner = inner, outer
+		rings[0], rings[1] = rings[1], rings[0]
+	}
+	checkBounds(t, "outer", outer, -1, -1, 12, 12)
+	checkBounds(t, "inner", inner, 1, 1, 8, 8)
+	a0, a1 := area(rings[0]), area(rings[1])
+	if math.Abs(math.Abs(a0)-144) > 0.01 || math.Abs(math.Abs(a1)-64) > 0.01 || a0*a1 >= 0 {
+		t.Errorf("edges enclose %v and %v, want 144 and 64 of opposite signs", a0, a1)
+	}
+}
+
+func TestOutlineZeroLength(t *testing.T) {
+	// repeated points do not change the outline.
+	var repeated, clean Path
+	repeated.MoveTo(0, 0)
+	repeated.LineTo(0, 0)
+	repeated.LineTo(5, 0)
+	repeated.LineTo(5, 0)
+	repeated.LineTo(5, 0)
+	clean.MoveTo(0, 0)
+	clean.LineTo(5, 0)
+	got, want := repeated.Outline(ConstantWidth(2)), clean.Outline(ConstantWidth(2))
+	for _, line := range got.Flatten(0.01) {
+		for _, pt := range line {
+			if math.IsNaN(pt.X) || math.IsNaN(pt.Y) {
+				t.Fatalf("outline has a point at %v", pt)
+			}
+		}
+	}
+	if math.Abs(got.Length()-want.Length()) > 1e-9 {
+		t.Errorf("outline with repeated points is %v long, want %v", got.Length(), want.Length())
+	}
+	checkBounds(t, "repeated", got, -1, -1, 7, 2)
+
+	// a path that stays in one place has nothing to outline.
+	var dot Path
+	dot.MoveTo(3, 3)
+	dot.LineTo(3, 3)
+	if outline := dot.Outline(ConstantWidth(2)); len(outline.Segments) != 0 {
+		t.Errorf("zero length path outlines to %v, want nothing", outline)
+	}
+}
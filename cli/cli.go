@@ -0,0 +1,242 @@
+// Package cli is a command line renderer for sketches registered by name.
+//
+// A program registers its sketches, usually in init functions, and calls Main:
+//
+//	func main() {
+//		cli.Register("circles", renderCircles)
+//		cli.Main()
+//	}
+//
+// The resulting binary renders any registered sketch to any target:
+//
+//	sketches -sketch circles -target gif -width 400 -height 400 -frames 60 -view
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/blgg/render"
+)
+
+var sketches = map[string]render.FrameFunc{}
+
+// Register adds a sketch to the command line under the given name.
+// It should be called before Main or Run, typically from an init function.
+func Register(name string, frameFunc render.FrameFunc) {
+	if _, ok := sketches[name]; ok {
+		panic("cli: sketch registered twice: " + name)
+	}
+	sketches[name] = frameFunc
+}
+
+// Sketches returns the names of all registered sketches, sorted.
+func Sketches() []string {
+	var names []string
+	for name := range sketches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Main runs the command line with the program's arguments and exits on error.
+func Main() {
+	err := Run(os.Args[1:], os.Stdout)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// config holds the parsed command line flags.
+type config struct {
+	sketch  string
+	target  string
+	width   float64
+	height  float64
+	frames  int
+	fps     float64
+	percent float64
+	out     string
+	seed    int64
+	view    bool
+	list    bool
+	addr    string
+	loop    string
+	check   bool
+	noopt   bool
+}
+
+// Run parses the given arguments and renders the chosen sketch.
+// The sketch is named with -sketch or by the first argument that is not a flag.
+// Listings, the seed used and plotter optimization stats are written to out.
+func Run(args []string, out io.Writer) error {
+	var c config
+	flags := flag.NewFlagSet("blgg", flag.ContinueOnError)
+	flags.StringVar(&c.sketch, "sketch", "", "name of the sketch to render")
+	flags.StringVar(&c.target, "target", "image", "image, svg, pdf, hpgl, gcode, gif, apng, video, y4m, avi, spritesheet or preview")
+	flags.Float64Var(&c.width, "width", 800, "width of each frame")
+	flags.Float64Var(&c.height, "height", 800, "height of each frame")
+	flags.IntVar(&c.frames, "frames", 60, "number of frames to render for animated targets")
+	flags.Float64Var(&c.fps, "fps", 30, "frames per second for animated targets")
+	flags.Float64Var(&c.percent, "percent", 0, "percent passed to the sketch for the image, svg, pdf and plotter targets")
+	flags.StringVar(&c.out, "out", "", "output path, defaults to out with the target's extension")
+	flags.Int64Var(&c.seed, "seed", 0, "seed for the context's random numbers, 0 picks one from the clock")
+	flags.BoolVar(&c.view, "view", false, "open the result in a viewer when done")
+	flags.BoolVar(&c.list, "list", false, "list the registered sketches")
+	flags.StringVar(&c.addr, "addr", "localhost:8080", "address the preview target serves on")
+	flags.StringVar(&c.loop, "loop", "repeat", "how animated targets play: repeat, once or pingpong")
+	flags.BoolVar(&c.check, "checkloop", false, "compare the first and last moments of the sketch instead of rendering")
+	flags.BoolVar(&c.noopt, "noopt", false, "write plotter output in drawing order, without removing overlaps or shortening travel")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if c.list {
+		for _, name := range Sketches() {
+			fmt.Fprintln(out, name)
+		}
+		return nil
+	}
+	// the sketch can also be named by the first argument, with more flags after it.
+	if c.sketch == "" && flags.NArg() > 0 {
+		c.sketch = flags.Arg(0)
+		err = flags.Parse(flags.Args()[1:])
+		if err != nil {
+			return err
+		}
+	}
+	frameFunc, ok := sketches[c.sketch]
+	if !ok {
+		return fmt.Errorf("cli: unknown sketch %q, use -list to see the registered sketches", c.sketch)
+	}
+
+	if c.seed == 0 {
+		c.seed = time.Now().UnixNano()
+	}
+	fmt.Fprintf(out, "seed: %d\n", c.seed)
+
+	loop, err := loopMode(c.loop)
+	if err != nil {
+		return err
+	}
+	if c.check {
+		check := render.CheckLoop(c.width, c.height, frameFunc, render.Options{Seed: c.seed})
+		fmt.Fprintf(out, "loop: %s\n", check)
+		return nil
+	}
+
+	if c.out == "" {
+		c.out = "out" + extension(c.target)
+	}
+	err = renderTarget(c, loop, frameFunc, out)
+	if err != nil || !c.view {
+		return err
+	}
+	if c.target == "video" || c.target == "avi" || c.target == "y4m" {
+		return render.VLC(c.out, true)
+	}
+	return render.ViewImage(c.out)
+}
+
+// extension returns the default file extension for a target.
+func extension(target string) string {
+	switch target {
+	case "svg":
+		return ".svg"
+	case "pdf":
+		return ".pdf"
+	case "hpgl":
+		return ".hpgl"
+	case "gcode":
+		return ".gcode"
+	case "gif":
+		return ".gif"
+	case "video":
+		return ".mp4"
+	case "y4m":
+		return ".y4m"
+	case "avi":
+		return ".avi"
+	}
+	return ".png"
+}
+
+// loopMode converts the -loop flag into a render loop mode.
+func loopMode(name string) (int, error) {
+	switch name {
+	case "repeat":
+		return render.LoopRepeat, nil
+	case "once":
+		return render.LoopOnce, nil
+	case "pingpong":
+		return render.LoopPingPong, nil
+	}
+	return 0, fmt.Errorf("cli: unknown loop mode %q", name)
+}
+
+// renderTarget renders the sketch to the chosen target.
+// Every target is given the seed, so the same seed renders the same output from sketches
+// that use the context's random helpers, and png output records the sketch and seed it came from.
+func renderTarget(c config, loop int, frameFunc render.FrameFunc, out io.Writer) error {
+	base := render.Options{Seed: c.seed, Metadata: &render.Metadata{Sketch: c.sketch}, Loop: loop}
+	switch c.target {
+	case "image":
+		return render.Image(c.width, c.height, c.out, frameFunc, c.percent, base)
+	case "svg":
+		return render.SVG(c.width, c.height, c.out, frameFunc, c.percent, base)
+	case "pdf":
+		return render.PDF(c.width, c.height, 1, c.out, frameFunc, render.PDFOptions{Options: base, Percents: []float64{c.percent}})
+	case "hpgl", "gcode":
+		format := render.HPGL
+		if c.target == "gcode" {
+			format = render.GCode
+		}
+		stats, err := render.Plot(c.width, c.height, c.out, frameFunc, c.percent, render.PlotOptions{Options: base, Format: format, Paper: render.PageA4, Optimize: !c.noopt})
+		if err != nil {
+			return err
+		}
+		if !c.noopt {
+			fmt.Fprintln(out, stats)
+		}
+		return nil
+	case "gif":
+		return render.GIF(c.width, c.height, c.frames, c.out, frameFunc, render.GIFOptions{Options: base, FPS: c.fps})
+	case "apng":
+		return render.APNG(c.width, c.height, c.frames, c.out, frameFunc, render.APNGOptions{Options: base, FPS: c.fps, Optimize: true})
+	case "y4m":
+		return render.Y4M(c.width, c.height, c.frames, c.out, frameFunc, render.Y4MOptions{Options: base, FPS: c.fps})
+	case "avi":
+		return render.AVI(c.width, c.height, c.frames, c.out, frameFunc, render.AVIOptions{Options: base, FPS: c.fps})
+	case "spritesheet":
+		return render.SpriteSheet(c.width, c.height, blcolor.White(), c.out, c.frames, frameFunc, render.SpriteSheetOptions{Options: base})
+	case "video":
+		frames, err := os.MkdirTemp("", "blgg-frames")
+		if err != nil {
+			return &render.FileError{Op: "mkdir", Path: os.TempDir(), Err: err}
+		}
+		defer os.RemoveAll(frames)
+		frames = filepath.Join(frames, "frames")
+		err = render.Frames(c.width, c.height, c.frames, frames, frameFunc, render.FramesOptions{Options: base})
+		if err != nil {
+			return err
+		}
+		options := render.YoutubePreset
+		options.FPS = c.fps
+		return render.ConvertToVideo(frames, c.out, options)
+	case "preview":
+		return render.Preview(c.addr, c.width, c.height, frameFunc, render.PreviewOptions{Options: base, NumFrames: c.frames, FPS: c.fps})
+	}
+	return fmt.Errorf("cli: unknown target %q", c.target)
+}
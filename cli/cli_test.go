@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func init() {
+	Register("gray", func(context *blgg.Context, width, height, percent float64) {
+		context.ClearRandomGray()
+	})
+	// the line is drawn twice, so optimizing removes one copy.
+	Register("twice", func(context *blgg.Context, width, height, percent float64) {
+		for i := 0; i < 2; i++ {
+			context.MoveTo(10, 10)
+			context.LineTo(width-10, height-10)
+			context.Stroke()
+		}
+	})
+}
+
+func TestRunPlotStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.hpgl")
+	var out bytes.Buffer
+	err := Run([]string{"-sketch", "twice", "-target", "hpgl", "-width", "100", "-height", "100", "-seed", "1", "-out", path}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "lines 2 -> 1") {
+		t.Errorf("output %q does not report the optimization", out.String())
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+// renderGray runs the gray sketch with a seed and returns the rendered image.
+func renderGray(t *testing.T, seed string) image.Image {
+	path := filepath.Join(t.TempDir(), "gray.png")
+	var out bytes.Buffer
+	err := Run([]string{"gray", "-width", "4", "-height", "4", "-seed", seed, "-out", path}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "seed: "+seed+"\n" {
+		t.Errorf("output %q does not report the seed", out.String())
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 4 || size.Y != 4 {
+		t.Errorf("image is %v, want 4x4", size)
+	}
+	return img
+}
+
+func TestRunImage(t *testing.T) {
+	a, b, c := renderGray(t, "5"), renderGray(t, "5"), renderGray(t, "6")
+	if a.At(0, 0) != b.At(0, 0) {
+		t.Error("the same seed rendered different images")
+	}
+	if a.At(0, 0) == c.At(0, 0) {
+		t.Error("different seeds rendered the same image")
+	}
+}
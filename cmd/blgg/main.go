@@ -0,0 +1,31 @@
+// Package main is a command line renderer for the example sketches.
+// Run it with -list to see them, or -h for all of the options.
+package main
+
+import (
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/blgg"
+	"github.com/bit101/blgg/cli"
+)
+
+func main() {
+	cli.Register("circle", renderCircle)
+	cli.Register("dot", renderDot)
+	cli.Main()
+}
+
+func renderCircle(context *blgg.Context, width, height, percent float64) {
+	context.BlackOnWhite()
+	context.Push()
+	context.TranslateCenter()
+	context.DrawAxes()
+	r := blmath.LerpSin(percent, 0, width/2)
+	context.FillCircle(0, 0, r)
+	context.Pop()
+}
+
+func renderDot(context *blgg.Context, width, height, percent float64) {
+	context.SetBlack()
+	r := blmath.LerpSin(percent, 2, width*0.45)
+	context.FillCircle(width/2, height/2, r)
+}
@@ -2,6 +2,8 @@
 package blgg
 
 import (
+	"math/rand"
+
 	"github.com/bit101/bitlib/blcolor"
 	"github.com/bit101/bitlib/blmath"
 	"github.com/bit101/bitlib/geom"
@@ -13,14 +15,33 @@ import (
 type Context struct {
 	gg.Context
 	ClampColors bool
+	scale       float64
+	rng         *rand.Rand
+	seed        int64
+	recording   *DisplayList
 }
 
 // NewContext creates a new blgg context with the given width and height.
 func NewContext(w, h int) *Context {
 	context := &Context{
-		*gg.NewContext(w, h),
-		true,
+		Context:     *gg.NewContext(w, h),
+		ClampColors: true,
+		scale:       1,
+	}
+	return context
+}
+
+// NewContextScaled creates a new blgg context with pixel dimensions scale times the given width and height.
+// Drawing is scaled to match, so code written for a w x h context produces the same image at a higher resolution.
+// Size and Center report the unscaled size.
+func NewContextScaled(w, h int, scale float64) *Context {
+	context := &Context{
+		Context:     *gg.NewContext(int(float64(w)*scale), int(float64(h)*scale)),
+		ClampColors: true,
+		scale:       scale,
 	}
+	context.Identity()
+	context.SetLineWidth(1)
 	return context
 }
 
@@ -43,7 +64,69 @@ func (c *Context) Center() *geom.Point {
 
 // Size returns the width and height of the image as float64s.
 func (c *Context) Size() (float64, float64) {
-	return float64(c.Width()), float64(c.Height())
+	return float64(c.Width()) / c.scale, float64(c.Height()) / c.scale
+}
+
+// PixelScale returns the number of pixels per unit, as set by NewContextScaled.
+func (c *Context) PixelScale() float64 {
+	return c.scale
+}
+
+// Identity resets the transform, keeping the scale set by NewContextScaled.
+func (c *Context) Identity() {
+	c.record("Identity")
+	c.Context.Identity()
+	if c.scale != 1 {
+		c.Context.Scale(c.scale, c.scale)
+	}
+}
+
+// SetLineWidth sets the line width in unscaled units.
+func (c *Context) SetLineWidth(lineWidth float64) {
+	c.record("SetLineWidth", lineWidth)
+	c.Context.SetLineWidth(lineWidth * c.scale)
+}
+
+// DrawPoint draws a circle at a point with a radius in unscaled units.
+func (c *Context) DrawPoint(x, y, r float64) {
+	c.record("DrawPoint", x, y, r)
+	c.Context.DrawPoint(x, y, r*c.scale)
+}
+
+// //////////////////
+// RANDOM
+// //////////////////
+
+// Seed gives the context its own random generator with the given seed.
+// Random helpers such as SetRandomRGB and FractalLine use it instead of the global random,
+// so the same seed always draws the same image.
+func (c *Context) Seed(seed int64) {
+	c.rng = rand.New(rand.NewSource(seed))
+	c.seed = seed
+}
+
+// RandomSeed returns the seed given to Seed, or 0 if the context uses the global random.
+func (c *Context) RandomSeed() int64 {
+	if c.rng == nil {
+		return 0
+	}
+	return c.seed
+}
+
+// RandomFloat returns a random float64 from 0 to 1, from the context's generator if it has been seeded.
+func (c *Context) RandomFloat() float64 {
+	if c.rng == nil {
+		return random.Float()
+	}
+	return c.rng.Float64()
+}
+
+// RandomRange returns a random float64 between min and max, from the context's generator if it has been seeded.
+func (c *Context) RandomRange(min, max float64) float64 {
+	if c.rng == nil {
+		return random.FloatRange(min, max)
+	}
+	return min + c.rng.Float64()*(max-min)
 }
 
 // //////////////////
@@ -96,12 +179,12 @@ func (c *Context) ClearHSVA(h, s, v, a float64) {
 
 // ClearRandomGray clears the image to a random shade of gray.
 func (c *Context) ClearRandomGray() {
-	c.ClearGray(random.Float())
+	c.ClearGray(c.RandomFloat())
 }
 
 // ClearRandomRGB clears the image to a random rgb value.
 func (c *Context) ClearRandomRGB() {
-	c.ClearRGB(random.Float(), random.Float(), random.Float())
+	c.ClearRGB(c.RandomFloat(), c.RandomFloat(), c.RandomFloat())
 }
 
 // ClearRGB clears the image to the given rgb value.
@@ -161,12 +244,12 @@ func (c *Context) SetHSVA(h, s, v, a float64) {
 
 // SetRandomGray sets the drawing color to a random gray shade.
 func (c *Context) SetRandomGray() {
-	c.SetGray(random.Float())
+	c.SetGray(c.RandomFloat())
 }
 
 // SetRandomRGB sets the drawing color to a random rgb value.
 func (c *Context) SetRandomRGB() {
-	c.SetRGB(random.Float(), random.Float(), random.Float())
+	c.SetRGB(c.RandomFloat(), c.RandomFloat(), c.RandomFloat())
 }
 
 // SetRGB clears the image to the given rgb value.
@@ -176,7 +259,7 @@ func (c *Context) SetRGB(r, g, b float64) {
 		g = blmath.Clamp(g, 0, 1)
 		b = blmath.Clamp(b, 0, 1)
 	}
-	c.Context.SetRGBA(r, g, b, 1.0)
+	c.SetRGBA(r, g, b, 1.0)
 }
 
 // SetWhite sets the drawing color to white.
@@ -185,8 +268,18 @@ func (c *Context) SetWhite() {
 }
 
 // SetPixelF sets the given pixel to the active drawing color, using float64 coords.
+// On a scaled context, every pixel covering that unscaled pixel is set.
 func (c *Context) SetPixelF(x, y float64) {
-	c.SetPixel(int(x), int(y))
+	c.record("SetPixelF", x, y)
+	if c.scale == 1 {
+		c.Context.SetPixel(int(x), int(y))
+		return
+	}
+	for py := int(y * c.scale); py < int((y+1)*c.scale); py++ {
+		for px := int(x * c.scale); px < int((x+1)*c.scale); px++ {
+			c.Context.SetPixel(px, py)
+		}
+	}
 }
 
 // ProcessPixels runs a function for every pixel in the context.
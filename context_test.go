@@ -0,0 +1,14 @@
+package blgg
+
+import "testing"
+
+func TestInvertYScaled(t *testing.T) {
+	for _, scale := range []float64{1, 2} {
+		c := NewContextScaled(200, 200, scale)
+		c.InvertY()
+		x, y := c.TransformPoint(10, 10)
+		if x != 10*scale || y != 190*scale {
+			t.Errorf("scale %v: (10, 10) is at (%v, %v), want (%v, %v)", scale, x, y, 10*scale, 190*scale)
+		}
+	}
+}
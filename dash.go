@@ -0,0 +1,177 @@
+// Package blgg is the main package for this module.
+package blgg
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// Mark is a place along a path where a dot or stamp is drawn.
+type Mark struct {
+	Point geom.Point
+	// Angle is the direction of the path at the mark, in radians.
+	Angle float64
+	// Distance is how far along its subpath the mark is.
+	Distance float64
+}
+
+// Dash returns the dashes of the path as a new path, cut by following the path's curves.
+// The pattern alternates the lengths of dashes and gaps, and like SetDash, a pattern with an odd
+// number of lengths is repeated to make it even. Offset is how far into the pattern each subpath
+// starts, so increasing it over an animation moves the dashes back along the path; it loops when it
+// grows by the sum of the pattern. Dashes carry on around corners, and on a closed subpath a dash
+// that crosses the start is drawn as one piece. An empty pattern returns the path unchanged.
+func (p Path) Dash(pattern []float64, offset float64) Path {
+	if len(pattern)%2 == 1 {
+		pattern = append(append([]float64(nil), pattern...), pattern...)
+	}
+	total := 0.0
+	for _, length := range pattern {
+		if length < 0 {
+			return p
+		}
+		total += length
+	}
+	if total <= 0 {
+		return p
+	}
+
+	var dashed Path
+	for _, line := range p.measure() {
+		// find the place in the pattern at the start of the line.
+		index := 0
+		remaining := pattern[0]
+		phase := math.Mod(offset, total)
+		if phase < 0 {
+			phase += total
+		}
+		for phase >= remaining {
+			phase -= remaining
+			index = (index + 1) % len(pattern)
+			remaining = pattern[index]
+		}
+		remaining -= phase
+
+		var pieces [][]geom.Point
+		// whether a dash is being drawn at the start and end of the line.
+		var startOn, endOn bool
+		pos := 0.0
+		length := line.length()
+		for pos < length {
+			end := math.Min(pos+remaining, length)
+			if index%2 == 0 {
+				pieces = append(pieces, line.slice(pos, end))
+				startOn = startOn || pos == 0
+				endOn = end == length
+			}
+			pos = end
+			index = (index + 1) % len(pattern)
+			remaining = pattern[index]
+		}
+		if len(pieces) == 0 {
+			continue
+		}
+		// a dash running off the end of a closed line carries on into the first one.
+		if len(pieces) > 1 && line.closed() && startOn && endOn {
+			last := pieces[len(pieces)-1]
+			pieces[0] = append(last, pieces[0][1:]...)
+			pieces = pieces[:len(pieces)-1]
+		}
+		for _, piece := range pieces {
+			dashed.MoveTo(piece[0].X, piece[0].Y)
+			for _, pt := range piece[1:] {
+				dashed.LineTo(pt.X, pt.Y)
+			}
+		}
+	}
+	return dashed
+}
+
+// Marks returns evenly spaced places along each subpath of the path, starting offset along it.
+// On a closed subpath the spacing is adjusted slightly so that a whole number of marks fits
+// around it, and the marks carry on around the start without bunching up.
+func (p Path) Marks(spacing, offset float64) []Mark {
+	if spacing <= 0 {
+		return nil
+	}
+	var marks []Mark
+	for _, line := range p.measure() {
+		length := line.length()
+		if line.closed() {
+			// the end is the start, so there are as many marks as steps around.
+			n := math.Max(1, math.Round(length/spacing))
+			step := length / n
+			start := math.Mod(offset, step)
+			if start < 0 {
+				start += step
+			}
+			for i := 0.0; i < n; i++ {
+				marks = append(marks, line.mark(start+step*i))
+			}
+			continue
+		}
+		start := math.Mod(offset, spacing)
+		if start < 0 {
+			start += spacing
+		}
+		for d := start; d <= length; d += spacing {
+			marks = append(marks, line.mark(d))
+		}
+	}
+	return marks
+}
+
+// mark returns the mark a distance along the polyline.
+func (l polyline) mark(distance float64) Mark {
+	point, angle := l.at(distance)
+	return Mark{point, angle, distance}
+}
+
+// slice returns the part of the polyline between two distances along it.
+func (l polyline) slice(d0, d1 float64) []geom.Point {
+	start, _ := l.at(d0)
+	end, _ := l.at(d1)
+	points := []geom.Point{start}
+	for i, d := range l.dists {
+		if d > d0 && d < d1 {
+			points = append(points, l.points[i])
+		}
+	}
+	return append(points, end)
+}
+
+////////////////////
+// DASHED PATHS
+////////////////////
+
+// DashedPath draws the dashes of a path, following its curves. See Path.Dash.
+func (c *Context) DashedPath(path Path, pattern []float64, offset float64) {
+	path.Dash(pattern, offset).Draw(c)
+}
+
+// StrokeDashedPath draws the dashes of a path and strokes them.
+func (c *Context) StrokeDashedPath(path Path, pattern []float64, offset float64) {
+	c.DashedPath(path, pattern, offset)
+	c.Stroke()
+}
+
+// DotPath draws a filled circle of the given radius at each mark along a path. See Path.Marks.
+func (c *Context) DotPath(path Path, spacing, offset, radius float64) {
+	for _, mark := range path.Marks(spacing, offset) {
+		c.DrawCircle(mark.Point.X, mark.Point.Y, radius)
+		c.Fill()
+	}
+}
+
+// StampPath calls stamp at each mark along a path, with the context translated to the mark
+// and rotated so that x points along the path. Anything drawn at the origin follows the path.
+func (c *Context) StampPath(path Path, spacing, offset float64, stamp func(c *Context)) {
+	for _, mark := range path.Marks(spacing, offset) {
+		c.Push()
+		c.Translate(mark.Point.X, mark.Point.Y)
+		c.Rotate(mark.Angle)
+		stamp(c)
+		c.Pop()
+	}
+}
@@ -0,0 +1,122 @@
+package blgg
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// dashEnds returns where each dash of a dashed path starts and ends.
+func dashEnds(p Path) [][2]geom.Point {
+	var ends [][2]geom.Point
+	for _, line := range p.Flatten(0.1) {
+		ends = append(ends, [2]geom.Point{line[0], line[len(line)-1]})
+	}
+	return ends
+}
+
+func TestDash(t *testing.T) {
+	var line Path
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+	x := func(x0, x1 float64) [2]geom.Point {
+		return [2]geom.Point{{X: x0}, {X: x1}}
+	}
+	tests := []struct {
+		name    string
+		pattern []float64
+		offset  float64
+		want    [][2]geom.Point
+	}{
+		{"no offset", []float64{2, 1}, 0, [][2]geom.Point{x(0, 2), x(3, 5), x(6, 8), x(9, 10)}},
+		{"offset", []float64{2, 1}, 0.5, [][2]geom.Point{x(0, 1.5), x(2.5, 4.5), x(5.5, 7.5), x(8.5, 10)}},
+		{"negative offset", []float64{2, 1}, -0.5, [][2]geom.Point{x(0.5, 2.5), x(3.5, 5.5), x(6.5, 8.5), x(9.5, 10)}},
+		{"offset of a whole pattern", []float64{2, 1}, 3, [][2]geom.Point{x(0, 2), x(3, 5), x(6, 8), x(9, 10)}},
+		{"odd pattern", []float64{2}, 0, [][2]geom.Point{x(0, 2), x(4, 6), x(8, 10)}},
+	}
+	for _, tt := range tests {
+		got := dashEnds(line.Dash(tt.pattern, tt.offset))
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: dashes %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !near(got[i][0], tt.want[i][0]) || !near(got[i][1], tt.want[i][1]) {
+				t.Errorf("%s: dash %d is %v, want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestDashClosed(t *testing.T) {
+	// the square is 16 around. Starting 2 into a pattern of 3 and 1, dashes begin at 2, 6, 10 and 14,
+	// and the last one runs across the start to 1.
+	square := RectanglePath(0, 0, 4, 4)
+	dashed := square.Dash([]float64{3, 1}, 2)
+	got := dashed.Flatten(0.1)
+	if len(got) != 4 {
+		t.Fatalf("%d dashes, want 4 with the one across the start in one piece", len(got))
+	}
+	wrapped := []geom.Point{{X: 0, Y: 2}, {X: 0, Y: 0}, {X: 1, Y: 0}}
+	if len(got[0]) != len(wrapped) {
+		t.Fatalf("dash across the start is %v, want %v", got[0], wrapped)
+	}
+	for i := range wrapped {
+		if !near(got[0][i], wrapped[i]) {
+			t.Errorf("dash across the start is %v, want %v", got[0], wrapped)
+			break
+		}
+	}
+	if length := dashed.Length(); math.Abs(length-12) > 1e-9 {
+		t.Errorf("dashes are %v long, want 12", length)
+	}
+}
+
+func TestDashUnchanged(t *testing.T) {
+	square := RectanglePath(0, 0, 4, 4)
+	for name, pattern := range map[string][]float64{
+		"empty":    nil,
+		"all zero": {0, 0},
+		"negative": {2, -1},
+	} {
+		if got := square.Dash(pattern, 1); !reflect.DeepEqual(got, square) {
+			t.Errorf("%s pattern changed the path to %v", name, got)
+		}
+	}
+}
+
+func TestMarks(t *testing.T) {
+	var line Path
+	line.MoveTo(0, 0)
+	line.LineTo(10, 0)
+	for _, spacing := range []float64{0, -1} {
+		if marks := line.Marks(spacing, 0); marks != nil {
+			t.Errorf("spacing %v gives %d marks, want none", spacing, len(marks))
+		}
+	}
+
+	marks := line.Marks(3, -2)
+	var got []float64
+	for _, mark := range marks {
+		got = append(got, mark.Distance)
+		if mark.Point.X != mark.Distance || mark.Angle != 0 {
+			t.Errorf("mark %v is not on the line", mark)
+		}
+	}
+	if want := []float64{1, 4, 7, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("marks at %v, want %v", got, want)
+	}
+
+	// 16 around at a spacing of 5 fits 3 marks, 16/3 apart.
+	marks = RectanglePath(0, 0, 4, 4).Marks(5, 0)
+	if len(marks) != 3 {
+		t.Fatalf("%d marks around the square, want 3", len(marks))
+	}
+	for i, mark := range marks {
+		if want := 16.0 / 3 * float64(i); math.Abs(mark.Distance-want) > 1e-9 {
+			t.Errorf("mark %d at %v, want %v", i, mark.Distance, want)
+		}
+	}
+}
@@ -2,6 +2,8 @@
 package main
 
 import (
+	"log"
+
 	"github.com/bit101/bitlib/blcolor"
 	"github.com/bit101/bitlib/blmath"
 	"github.com/bit101/blgg"
@@ -11,29 +13,54 @@ import (
 func main() {
 	target := render.GifTarget
 
+	err := run(target)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(target int) error {
 	switch target {
 	case render.ImageTarget:
-		render.Image(800, 800, "out.png", renderFrame, 0.5)
-		render.ViewImage("out.png")
-		break
+		err := render.Image(800, 800, "out.png", renderFrame, 0.5, render.Options{Supersample: 2})
+		if err != nil {
+			return err
+		}
+		return render.ViewImage("out.png")
 
 	case render.SpriteSheetTarget:
-		render.SpriteSheet(40, 40, blcolor.White(), "out.png", 25, renderSpriteSheetFrame)
-		render.ViewImage("out.png")
-		break
+		err := render.SpriteSheet(40, 40, blcolor.White(), "out.png", 25, renderSpriteSheetFrame, render.SpriteSheetOptions{Padding: 2, Atlas: "out.json"})
+		if err != nil {
+			return err
+		}
+		return render.ViewImage("out.png")
 
 	case render.GifTarget:
-		render.Frames(400, 400, 60, "frames", renderFrame)
-		render.MakeGIF("ffmpeg", "frames", "out.gif", 30)
-		render.ViewImage("out.gif")
-		break
+		err := render.GIF(400, 400, 60, "out.gif", renderFrame, render.GIFOptions{FPS: 30})
+		if err != nil {
+			return err
+		}
+		return render.ViewImage("out.gif")
+
+	case render.APNGTarget:
+		err := render.APNG(400, 400, 60, "out.png", renderFrame, render.APNGOptions{FPS: 30, Optimize: true})
+		if err != nil {
+			return err
+		}
+		return render.ViewImage("out.png")
 
 	case render.VideoTarget:
-		render.Frames(1280, 800, 60, "frames", renderFrame)
-		render.ConvertToYoutube("frames", "out.mp4", 60)
-		render.VLC("out.mp4", true)
-		break
+		err := render.Frames(1280, 800, 60, "frames", renderFrame, render.FramesOptions{})
+		if err != nil {
+			return err
+		}
+		err = render.ConvertToYoutube("frames", "out.mp4", 60)
+		if err != nil {
+			return err
+		}
+		return render.VLC("out.mp4", true)
 	}
+	return nil
 }
 
 func renderFrame(context *blgg.Context, width, height, percent float64) {
@@ -0,0 +1,395 @@
+// Package blgg is the main package for this module.
+package blgg
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+const (
+	// SegmentMove starts a new subpath at its point.
+	SegmentMove = iota
+	// SegmentLine is a straight line to its point.
+	SegmentLine
+	// SegmentQuad is a quadratic bezier curve through a control point to its second point.
+	SegmentQuad
+	// SegmentCubic is a cubic bezier curve through two control points to its third point.
+	SegmentCubic
+	// SegmentClose is a straight line back to the start of the subpath. It has no points.
+	SegmentClose
+	// SegmentArc is the elliptical arc described by its Arc, starting at the current point. Its point is where it ends.
+	SegmentArc
+)
+
+// measureTolerance is how closely paths are flattened to measure them, in the path's units.
+const measureTolerance = 0.001
+
+// Segment is one part of a path.
+type Segment struct {
+	Kind   int
+	Points []geom.Point
+	// Arc describes a SegmentArc and is nil for other kinds.
+	Arc *Arc
+}
+
+// Arc is an elliptical arc around a center, from angle Start to angle End in radians.
+type Arc struct {
+	Center     geom.Point
+	RX, RY     float64
+	Start, End float64
+}
+
+// point returns the point on the arc's ellipse at an angle.
+func (a Arc) point(angle float64) geom.Point {
+	return geom.Point{X: a.Center.X + a.RX*math.Cos(angle), Y: a.Center.Y + a.RY*math.Sin(angle)}
+}
+
+// Path is a sequence of segments, made of one or more subpaths that each begin with a SegmentMove.
+// A path can be built with the same calls as drawing on a Context, measured, and drawn onto a Context.
+type Path struct {
+	Segments []Segment
+}
+
+// End returns the last point of the segment, or false for SegmentClose.
+func (s Segment) End() (geom.Point, bool) {
+	if len(s.Points) == 0 {
+		return geom.Point{}, false
+	}
+	return s.Points[len(s.Points)-1], true
+}
+
+////////////////////
+// BUILDING
+////////////////////
+
+// MoveTo starts a new subpath at a point.
+func (p *Path) MoveTo(x, y float64) {
+	p.add(SegmentMove, geom.Point{X: x, Y: y})
+}
+
+// LineTo adds a line to a point. Like a Context, it starts a subpath there if there is no current point.
+func (p *Path) LineTo(x, y float64) {
+	if _, ok := p.current(); !ok {
+		p.MoveTo(x, y)
+		return
+	}
+	p.add(SegmentLine, geom.Point{X: x, Y: y})
+}
+
+// QuadraticTo adds a quadratic bezier curve through a control point to a point.
+func (p *Path) QuadraticTo(x1, y1, x2, y2 float64) {
+	if _, ok := p.current(); !ok {
+		p.MoveTo(x1, y1)
+	}
+	p.add(SegmentQuad, geom.Point{X: x1, Y: y1}, geom.Point{X: x2, Y: y2})
+}
+
+// CubicTo adds a cubic bezier curve through two control points to a point.
+func (p *Path) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	if _, ok := p.current(); !ok {
+		p.MoveTo(x1, y1)
+	}
+	p.add(SegmentCubic, geom.Point{X: x1, Y: y1}, geom.Point{X: x2, Y: y2}, geom.Point{X: x3, Y: y3})
+}
+
+// DrawArc adds a circular arc.
+func (p *Path) DrawArc(x, y, r, a1, a2 float64) {
+	p.DrawEllipticalArc(x, y, r, r, a1, a2)
+}
+
+// DrawEllipticalArc adds an elliptical arc. Like a Context, it starts with a line from the
+// current point to the start of the arc, or starts a subpath there if there is no current point.
+func (p *Path) DrawEllipticalArc(x, y, rx, ry, a1, a2 float64) {
+	arc := &Arc{Center: geom.Point{X: x, Y: y}, RX: rx, RY: ry, Start: a1, End: a2}
+	start := arc.point(a1)
+	if current, ok := p.current(); !ok {
+		p.MoveTo(start.X, start.Y)
+	} else if current != start {
+		p.LineTo(start.X, start.Y)
+	}
+	p.Segments = append(p.Segments, Segment{Kind: SegmentArc, Points: []geom.Point{arc.point(a2)}, Arc: arc})
+}
+
+// ClosePath adds a line back to the start of the current subpath.
+func (p *Path) ClosePath() {
+	if _, ok := p.current(); ok {
+		p.add(SegmentClose)
+	}
+}
+
+func (p *Path) add(kind int, points ...geom.Point) {
+	p.Segments = append(p.Segments, Segment{Kind: kind, Points: points})
+}
+
+// current returns the point the next segment starts from, or false if the path is empty.
+func (p *Path) current() (geom.Point, bool) {
+	for i := len(p.Segments) - 1; i >= 0; i-- {
+		s := p.Segments[i]
+		if s.Kind == SegmentClose {
+			// a closed subpath carries on from its start.
+			for j := i - 1; j >= 0; j-- {
+				if p.Segments[j].Kind == SegmentMove {
+					return p.Segments[j].Points[0], true
+				}
+			}
+			return geom.Point{}, false
+		}
+		if end, ok := s.End(); ok {
+			return end, true
+		}
+	}
+	return geom.Point{}, false
+}
+
+////////////////////
+// MEASURING
+////////////////////
+
+// Flatten converts the path into polylines, one for each subpath, with curves replaced by
+// straight lines that stray no further than tolerance from them. A closed subpath ends with its first point.
+func (p Path) Flatten(tolerance float64) [][]geom.Point {
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+	var lines [][]geom.Point
+	var line []geom.Point
+	var start, current geom.Point
+	for _, s := range p.Segments {
+		switch s.Kind {
+		case SegmentMove:
+			if len(line) > 1 {
+				lines = append(lines, line)
+			}
+			start = s.Points[0]
+			line = []geom.Point{start}
+		case SegmentLine:
+			line = append(line, s.Points[0])
+		case SegmentQuad:
+			line = append(line, flattenCurve([]geom.Point{current, s.Points[0], s.Points[1]}, tolerance)...)
+		case SegmentCubic:
+			line = append(line, flattenCurve([]geom.Point{current, s.Points[0], s.Points[1], s.Points[2]}, tolerance)...)
+		case SegmentArc:
+			line = append(line, flattenArc(*s.Arc, tolerance)...)
+		case SegmentClose:
+			line = append(line, start)
+			if len(line) > 1 {
+				lines = append(lines, line)
+			}
+			// drawing may carry on from the start of a closed subpath.
+			line = []geom.Point{start}
+			current = start
+			continue
+		}
+		current, _ = s.End()
+	}
+	if len(line) > 1 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Length returns the length of the path, adding up all its subpaths.
+func (p Path) Length() float64 {
+	total := 0.0
+	for _, line := range p.measure() {
+		total += line.length()
+	}
+	return total
+}
+
+// PointAt returns the point a distance along the path, counting along its subpaths in order.
+// Distances before the start or past the end are clamped to the ends. For the point 40% of the way along,
+// use p.PointAt(p.Length() * 0.4).
+func (p Path) PointAt(distance float64) geom.Point {
+	point, _ := p.at(distance)
+	return point
+}
+
+// TangentAt returns the direction of the path, in radians, a distance along it.
+func (p Path) TangentAt(distance float64) float64 {
+	_, angle := p.at(distance)
+	return angle
+}
+
+// Bounds returns the smallest rectangle holding the whole path, to within a small flattening tolerance.
+func (p Path) Bounds() (x, y, w, h float64) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, line := range p.Flatten(measureTolerance) {
+		for _, pt := range line {
+			minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+			maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+		}
+	}
+	// a path of lone moves still has a position.
+	for _, s := range p.Segments {
+		if s.Kind == SegmentMove {
+			minX, minY = math.Min(minX, s.Points[0].X), math.Min(minY, s.Points[0].Y)
+			maxX, maxY = math.Max(maxX, s.Points[0].X), math.Max(maxY, s.Points[0].Y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return 0, 0, 0, 0
+	}
+	return minX, minY, maxX - minX, maxY - minY
+}
+
+// at returns the point and direction a distance along the path.
+func (p Path) at(distance float64) (geom.Point, float64) {
+	lines := p.measure()
+	if len(lines) == 0 {
+		return geom.Point{}, 0
+	}
+	for i, line := range lines {
+		if distance <= line.length() || i == len(lines)-1 {
+			return line.at(distance)
+		}
+		distance -= line.length()
+	}
+	return geom.Point{}, 0
+}
+
+// measure flattens the path for measuring.
+func (p Path) measure() []polyline {
+	var lines []polyline
+	for _, points := range p.Flatten(measureTolerance) {
+		lines = append(lines, newPolyline(points))
+	}
+	return lines
+}
+
+// polyline is a flattened subpath with the distance along it to each of its points.
+type polyline struct {
+	points []geom.Point
+	dists  []float64
+}
+
+func newPolyline(points []geom.Point) polyline {
+	dists := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		dists[i] = dists[i-1] + math.Hypot(points[i].X-points[i-1].X, points[i].Y-points[i-1].Y)
+	}
+	return polyline{points, dists}
+}
+
+func (l polyline) length() float64 {
+	return l.dists[len(l.dists)-1]
+}
+
+// closed reports whether the polyline ends where it starts.
+func (l polyline) closed() bool {
+	return l.points[0] == l.points[len(l.points)-1]
+}
+
+// at returns the point and direction a distance along the polyline, clamped to its ends.
+// At a corner, the direction is that of the line leaving it.
+func (l polyline) at(distance float64) (geom.Point, float64) {
+	i := l.index(distance)
+	a, b := l.points[i], l.points[i+1]
+	angle := math.Atan2(b.Y-a.Y, b.X-a.X)
+	span := l.dists[i+1] - l.dists[i]
+	switch {
+	case distance <= l.dists[i] || span <= 0:
+		return a, angle
+	case distance >= l.dists[i+1]:
+		return b, angle
+	}
+	t := (distance - l.dists[i]) / span
+	return geom.Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}, angle
+}
+
+// index returns the index of the line, of length above zero where there is one, that holds a distance.
+func (l polyline) index(distance float64) int {
+	last := len(l.points) - 2
+	i := 0
+	for i < last && (l.dists[i+1] <= distance || l.dists[i+1] == l.dists[i]) {
+		i++
+	}
+	// zero length lines at the end take the direction of the line before them.
+	for i > 0 && l.dists[i+1] == l.dists[i] {
+		i--
+	}
+	return i
+}
+
+// flattenCurve returns points along a quadratic or cubic bezier curve, after its first point.
+// The number of points comes from Wang's formula, which bounds the distance from the curve.
+func flattenCurve(p []geom.Point, tolerance float64) []geom.Point {
+	degree := float64(len(p) - 1)
+	dd := 0.0
+	for i := 0; i+2 < len(p); i++ {
+		d := math.Hypot(p[i].X-2*p[i+1].X+p[i+2].X, p[i].Y-2*p[i+1].Y+p[i+2].Y)
+		dd = math.Max(dd, d)
+	}
+	n := int(math.Ceil(math.Sqrt(degree * (degree - 1) / 8 * dd / tolerance)))
+	if n < 1 {
+		n = 1
+	}
+	points := make([]geom.Point, n)
+	for i := 1; i <= n; i++ {
+		points[i-1] = bezierPoint(p, float64(i)/float64(n))
+	}
+	return points
+}
+
+// flattenArc returns points along an arc, after its first point, with steps small enough
+// that each chord strays no further than tolerance from the arc.
+func flattenArc(a Arc, tolerance float64) []geom.Point {
+	r := math.Max(math.Abs(a.RX), math.Abs(a.RY))
+	n := 1
+	if r > tolerance {
+		step := 2 * math.Acos(1-tolerance/r)
+		n = int(math.Ceil(math.Abs(a.End-a.Start) / step))
+		if n < 1 {
+			n = 1
+		}
+	}
+	points := make([]geom.Point, n)
+	for i := 1; i <= n; i++ {
+		points[i-1] = a.point(a.Start + (a.End-a.Start)*float64(i)/float64(n))
+	}
+	return points
+}
+
+// bezierPoint returns the point at t on a quadratic or cubic bezier curve.
+func bezierPoint(p []geom.Point, t float64) geom.Point {
+	u := 1 - t
+	if len(p) == 3 {
+		return geom.Point{
+			X: u*u*p[0].X + 2*u*t*p[1].X + t*t*p[2].X,
+			Y: u*u*p[0].Y + 2*u*t*p[1].Y + t*t*p[2].Y,
+		}
+	}
+	return geom.Point{
+		X: u*u*u*p[0].X + 3*u*u*t*p[1].X + 3*u*t*t*p[2].X + t*t*t*p[3].X,
+		Y: u*u*u*p[0].Y + 3*u*u*t*p[1].Y + 3*u*t*t*p[2].Y + t*t*t*p[3].Y,
+	}
+}
+
+////////////////////
+// DRAWING
+////////////////////
+
+// Draw adds the path to the context's current path, to be filled or stroked.
+// The context's transform applies to it as it would to any drawing.
+func (p Path) Draw(c *Context) {
+	for _, s := range p.Segments {
+		pt := s.Points
+		switch s.Kind {
+		case SegmentMove:
+			c.MoveTo(pt[0].X, pt[0].Y)
+		case SegmentLine:
+			c.LineTo(pt[0].X, pt[0].Y)
+		case SegmentQuad:
+			c.QuadraticTo(pt[0].X, pt[0].Y, pt[1].X, pt[1].Y)
+		case SegmentCubic:
+			c.CubicTo(pt[0].X, pt[0].Y, pt[1].X, pt[1].Y, pt[2].X, pt[2].Y)
+		case SegmentArc:
+			a := s.Arc
+			c.DrawEllipticalArc(a.Center.X, a.Center.Y, a.RX, a.RY, a.Start, a.End)
+		case SegmentClose:
+			c.ClosePath()
+		}
+	}
+}
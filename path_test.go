@@ -0,0 +1,151 @@
+package blgg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// cubicCircle returns a unit circle made of four cubic curves, as gg draws one.
+func cubicCircle() Path {
+	var p Path
+	k := 4.0 / 3.0 * math.Tan(math.Pi/8)
+	p.MoveTo(1, 0)
+	p.CubicTo(1, k, k, 1, 0, 1)
+	p.CubicTo(-k, 1, -1, k, -1, 0)
+	p.CubicTo(-1, -k, -k, -1, 0, -1)
+	p.CubicTo(k, -1, 1, -k, 1, 0)
+	p.ClosePath()
+	return p
+}
+
+func TestLength(t *testing.T) {
+	var line, quad, twoLines Path
+	line.MoveTo(0, 0)
+	line.LineTo(3, 4)
+	quad.MoveTo(0, 0)
+	quad.QuadraticTo(1, 0, 2, 0)
+	twoLines.MoveTo(0, 0)
+	twoLines.LineTo(3, 4)
+	twoLines.MoveTo(10, 10)
+	twoLines.LineTo(10, 12)
+
+	tests := []struct {
+		name string
+		path Path
+		want float64
+	}{
+		{"line", line, 5},
+		{"flat quadratic", quad, 2},
+		{"two subpaths", twoLines, 7},
+		{"arc circle", CirclePath(0, 0, 1), 2 * math.Pi},
+		{"cubic circle", cubicCircle(), 2 * math.Pi},
+		{"half arc", ArcPath(0, 0, 2, 0, math.Pi), 2 * math.Pi},
+		{"square", RectanglePath(0, 0, 2, 2), 8},
+		{"empty", Path{}, 0},
+	}
+	// chords fall a little inside curves, so lengths are within the flattening tolerance per unit of length.
+	for _, tt := range tests {
+		if got := tt.path.Length(); math.Abs(got-tt.want) > 1e-3*math.Max(1, tt.want) {
+			t.Errorf("%s: length %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPointAt(t *testing.T) {
+	var p Path
+	p.MoveTo(0, 0)
+	p.LineTo(10, 4)
+	length := p.Length()
+	tests := []struct {
+		distance float64
+		want     geom.Point
+	}{
+		{length / 2, geom.Point{X: 5, Y: 2}},
+		{0, geom.Point{X: 0, Y: 0}},
+		{-1, geom.Point{X: 0, Y: 0}},
+		{length, geom.Point{X: 10, Y: 4}},
+		{length + 5, geom.Point{X: 10, Y: 4}},
+	}
+	for _, tt := range tests {
+		if got := p.PointAt(tt.distance); !near(got, tt.want) {
+			t.Errorf("PointAt(%v) is %v, want %v", tt.distance, got, tt.want)
+		}
+	}
+	if got, want := p.TangentAt(length/2), math.Atan2(4, 10); math.Abs(got-want) > 1e-9 {
+		t.Errorf("tangent %v, want %v", got, want)
+	}
+
+	// the second subpath carries on from the length of the first.
+	p.MoveTo(20, 0)
+	p.LineTo(20, 10)
+	if got, want := p.PointAt(length+5), (geom.Point{X: 20, Y: 5}); !near(got, want) {
+		t.Errorf("PointAt in the second subpath is %v, want %v", got, want)
+	}
+}
+
+func TestBounds(t *testing.T) {
+	// a 4 by 2 rectangle centered on 5, 5, turned by 30 degrees.
+	var rotated Path
+	sin, cos := math.Sincos(math.Pi / 6)
+	for _, corner := range []geom.Point{{X: -2, Y: -1}, {X: 2, Y: -1}, {X: 2, Y: 1}, {X: -2, Y: 1}} {
+		rotated.LineTo(5+corner.X*cos-corner.Y*sin, 5+corner.X*sin+corner.Y*cos)
+	}
+	rotated.ClosePath()
+	halfW, halfH := 2*cos+sin, 2*sin+cos
+	var move Path
+	move.MoveTo(3, 4)
+
+	tests := []struct {
+		name       string
+		path       Path
+		x, y, w, h float64
+	}{
+		{"rectangle", RectanglePath(1, 2, 3, 4), 1, 2, 3, 4},
+		{"rotated rectangle", rotated, 5 - halfW, 5 - halfH, 2 * halfW, 2 * halfH},
+		{"circle", CirclePath(10, 20, 5), 5, 15, 10, 10},
+		{"cubic circle", cubicCircle(), -1, -1, 2, 2},
+		{"lone move", move, 3, 4, 0, 0},
+		{"empty", Path{}, 0, 0, 0, 0},
+	}
+	// curves are flattened to measure them, so each side may be inside by the flattening tolerance.
+	const within = 2 * measureTolerance
+	for _, tt := range tests {
+		x, y, w, h := tt.path.Bounds()
+		if math.Abs(x-tt.x) > within || math.Abs(y-tt.y) > within || math.Abs(w-tt.w) > within || math.Abs(h-tt.h) > within {
+			t.Errorf("%s: bounds %v %v %v %v, want %v %v %v %v", tt.name, x, y, w, h, tt.x, tt.y, tt.w, tt.h)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	// a closed subpath ends with its first point.
+	lines := RectanglePath(0, 0, 2, 1).Flatten(0.1)
+	if len(lines) != 1 || len(lines[0]) != 5 || lines[0][4] != lines[0][0] {
+		t.Fatalf("rectangle flattens to %v, want one closed line of 5 points", lines)
+	}
+
+	// every point is on the curve, and the middle of every chord is within tolerance of it.
+	for _, tolerance := range []float64{0.1, 0.01, 0.001} {
+		for name, path := range map[string]Path{"arc": CirclePath(0, 0, 1), "cubic": cubicCircle()} {
+			lines := path.Flatten(tolerance)
+			if len(lines) != 1 {
+				t.Fatalf("%s: %d lines, want 1", name, len(lines))
+			}
+			line := lines[0]
+			for i := 1; i < len(line); i++ {
+				a, b := line[i-1], line[i]
+				if r := math.Hypot(b.X, b.Y); math.Abs(r-1) > 1e-3 {
+					t.Errorf("%s at %v: point %v is off the circle", name, tolerance, b)
+				}
+				if r := math.Hypot((a.X+b.X)/2, (a.Y+b.Y)/2); 1-r > tolerance+1e-3 {
+					t.Errorf("%s at %v: chord from %v to %v strays %v", name, tolerance, a, b, 1-r)
+				}
+			}
+		}
+	}
+	if coarse, fine := len(CirclePath(0, 0, 1).Flatten(0.1)[0]), len(CirclePath(0, 0, 1).Flatten(0.001)[0]); fine <= coarse {
+		t.Errorf("a smaller tolerance gives %d points, no more than the %d of a larger one", fine, coarse)
+	}
+}
@@ -0,0 +1,466 @@
+package blgg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fogleman/gg"
+)
+
+// Op is a single recorded drawing call: the name of a Context method and its arguments.
+type Op struct {
+	Name string    `json:"op"`
+	Args []float64 `json:"args,omitempty"`
+}
+
+// DisplayList is a recording of the drawing calls made on a Context, in the order they were made.
+// Coordinates are recorded in the units the sketch drew with, so a list can be replayed at any scale.
+type DisplayList struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Ops    []Op    `json:"ops"`
+}
+
+// Record starts recording the context's drawing calls into a new display list.
+// Drawing still happens as normal while recording. Calls made directly on the embedded gg.Context,
+// text and images are drawn but not recorded.
+func (c *Context) Record() {
+	w, h := c.Size()
+	c.recording = &DisplayList{Width: w, Height: h}
+}
+
+// StopRecording stops recording and returns the display list, or nil if the context was not recording.
+func (c *Context) StopRecording() *DisplayList {
+	list := c.recording
+	c.recording = nil
+	return list
+}
+
+// Recording returns the display list being recorded, or nil if the context is not recording.
+func (c *Context) Recording() *DisplayList {
+	return c.recording
+}
+
+// record adds a call to the display list, if recording.
+func (c *Context) record(name string, args ...float64) {
+	if c.recording != nil {
+		c.recording.Ops = append(c.recording.Ops, Op{name, args})
+	}
+}
+
+// Replay makes the recorded calls on a context. Replaying onto a context from NewContextScaled
+// draws the same picture at a higher resolution. It stops at the first op it does not know
+// or that has the wrong number of arguments.
+func (d *DisplayList) Replay(c *Context) error {
+	for i, op := range d.Ops {
+		err := op.check(i)
+		if err != nil {
+			return err
+		}
+		replayers[op.Name].fn(c, op.Args)
+	}
+	return nil
+}
+
+// check reports an op that Replay would not know or that has the wrong number of arguments.
+func (o Op) check(i int) error {
+	r, ok := replayers[o.Name]
+	if !ok {
+		return fmt.Errorf("blgg: unknown op %q at %d", o.Name, i)
+	}
+	if (r.args >= 0 && len(o.Args) != r.args) || (r.args < 0 && len(o.Args) < -r.args-1) {
+		return fmt.Errorf("blgg: wrong number of args for %s at %d", o.Name, i)
+	}
+	return nil
+}
+
+// Render replays the list onto a new context at scale times its recorded size.
+func (d *DisplayList) Render(scale float64) (*Context, error) {
+	c := NewContextScaled(int(d.Width), int(d.Height), scale)
+	return c, d.Replay(c)
+}
+
+// Diff returns the index of every op that differs between two lists, including ops only one of them has.
+func (d *DisplayList) Diff(other *DisplayList) []int {
+	var diff []int
+	n := len(d.Ops)
+	if len(other.Ops) > n {
+		n = len(other.Ops)
+	}
+	for i := 0; i < n; i++ {
+		if i >= len(d.Ops) || i >= len(other.Ops) || !d.Ops[i].equal(other.Ops[i]) {
+			diff = append(diff, i)
+		}
+	}
+	return diff
+}
+
+func (o Op) equal(other Op) bool {
+	if o.Name != other.Name || len(o.Args) != len(other.Args) {
+		return false
+	}
+	for i, a := range o.Args {
+		if a != other.Args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveJSON writes the list to a json file.
+func (d *DisplayList) SaveJSON(path string) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadDisplayList reads a list written by SaveJSON.
+func LoadDisplayList(path string) (*DisplayList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	list := &DisplayList{}
+	err = json.Unmarshal(data, list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// replayer calls the method for an op. args is the number of arguments it takes,
+// or -(n+1) for a method taking at least n.
+type replayer struct {
+	args int
+	fn   func(c *Context, a []float64)
+}
+
+var replayers = map[string]replayer{
+	"MoveTo":               {2, func(c *Context, a []float64) { c.MoveTo(a[0], a[1]) }},
+	"LineTo":               {2, func(c *Context, a []float64) { c.LineTo(a[0], a[1]) }},
+	"QuadraticTo":          {4, func(c *Context, a []float64) { c.QuadraticTo(a[0], a[1], a[2], a[3]) }},
+	"CubicTo":              {6, func(c *Context, a []float64) { c.CubicTo(a[0], a[1], a[2], a[3], a[4], a[5]) }},
+	"ClosePath":            {0, func(c *Context, a []float64) { c.ClosePath() }},
+	"ClearPath":            {0, func(c *Context, a []float64) { c.ClearPath() }},
+	"NewSubPath":           {0, func(c *Context, a []float64) { c.NewSubPath() }},
+	"DrawPoint":            {3, func(c *Context, a []float64) { c.DrawPoint(a[0], a[1], a[2]) }},
+	"DrawLine":             {4, func(c *Context, a []float64) { c.DrawLine(a[0], a[1], a[2], a[3]) }},
+	"DrawRectangle":        {4, func(c *Context, a []float64) { c.DrawRectangle(a[0], a[1], a[2], a[3]) }},
+	"DrawRoundedRectangle": {5, func(c *Context, a []float64) { c.DrawRoundedRectangle(a[0], a[1], a[2], a[3], a[4]) }},
+	"DrawCircle":           {3, func(c *Context, a []float64) { c.DrawCircle(a[0], a[1], a[2]) }},
+	"DrawArc":              {5, func(c *Context, a []float64) { c.DrawArc(a[0], a[1], a[2], a[3], a[4]) }},
+	"DrawEllipse":          {4, func(c *Context, a []float64) { c.DrawEllipse(a[0], a[1], a[2], a[3]) }},
+	"DrawEllipticalArc":    {6, func(c *Context, a []float64) { c.DrawEllipticalArc(a[0], a[1], a[2], a[3], a[4], a[5]) }},
+	"DrawRegularPolygon":   {5, func(c *Context, a []float64) { c.DrawRegularPolygon(int(a[0]), a[1], a[2], a[3], a[4]) }},
+	"Fill":                 {0, func(c *Context, a []float64) { c.Fill() }},
+	"FillPreserve":         {0, func(c *Context, a []float64) { c.FillPreserve() }},
+	"Stroke":               {0, func(c *Context, a []float64) { c.Stroke() }},
+	"StrokePreserve":       {0, func(c *Context, a []float64) { c.StrokePreserve() }},
+	"Clip":                 {0, func(c *Context, a []float64) { c.Clip() }},
+	"ClipPreserve":         {0, func(c *Context, a []float64) { c.ClipPreserve() }},
+	"ResetClip":            {0, func(c *Context, a []float64) { c.ResetClip() }},
+	"Clear":                {0, func(c *Context, a []float64) { c.Clear() }},
+	"SetPixel":             {2, func(c *Context, a []float64) { c.SetPixel(int(a[0]), int(a[1])) }},
+	"SetPixelF":            {2, func(c *Context, a []float64) { c.SetPixelF(a[0], a[1]) }},
+	"Push":                 {0, func(c *Context, a []float64) { c.Push() }},
+	"Pop":                  {0, func(c *Context, a []float64) { c.Pop() }},
+	"Identity":             {0, func(c *Context, a []float64) { c.Identity() }},
+	"Translate":            {2, func(c *Context, a []float64) { c.Translate(a[0], a[1]) }},
+	"Rotate":               {1, func(c *Context, a []float64) { c.Rotate(a[0]) }},
+	"RotateAbout":          {3, func(c *Context, a []float64) { c.RotateAbout(a[0], a[1], a[2]) }},
+	"Scale":                {2, func(c *Context, a []float64) { c.Scale(a[0], a[1]) }},
+	"ScaleAbout":           {4, func(c *Context, a []float64) { c.ScaleAbout(a[0], a[1], a[2], a[3]) }},
+	"Shear":                {2, func(c *Context, a []float64) { c.Shear(a[0], a[1]) }},
+	"InvertY":              {0, func(c *Context, a []float64) { c.InvertY() }},
+	"SetRGBA":              {4, func(c *Context, a []float64) { c.SetRGBA(a[0], a[1], a[2], a[3]) }},
+	"SetLineWidth":         {1, func(c *Context, a []float64) { c.SetLineWidth(a[0]) }},
+	"SetLineCap":           {1, func(c *Context, a []float64) { c.SetLineCap(gg.LineCap(a[0])) }},
+	"SetLineJoin":          {1, func(c *Context, a []float64) { c.SetLineJoin(gg.LineJoin(a[0])) }},
+	"SetFillRule":          {1, func(c *Context, a []float64) { c.SetFillRule(gg.FillRule(a[0])) }},
+	"SetDash":              {-1, func(c *Context, a []float64) { c.SetDash(a...) }},
+	"SetDashOffset":        {1, func(c *Context, a []float64) { c.SetDashOffset(a[0]) }},
+}
+
+// //////////////////
+// RECORDED CALLS
+// //////////////////
+
+// MoveTo starts a new subpath at the given point.
+func (c *Context) MoveTo(x, y float64) {
+	c.record("MoveTo", x, y)
+	c.Context.MoveTo(x, y)
+}
+
+// LineTo adds a line segment to the current path.
+func (c *Context) LineTo(x, y float64) {
+	c.record("LineTo", x, y)
+	c.Context.LineTo(x, y)
+}
+
+// QuadraticTo adds a quadratic bezier curve to the current path.
+func (c *Context) QuadraticTo(x1, y1, x2, y2 float64) {
+	c.record("QuadraticTo", x1, y1, x2, y2)
+	c.Context.QuadraticTo(x1, y1, x2, y2)
+}
+
+// CubicTo adds a cubic bezier curve to the current path.
+func (c *Context) CubicTo(x1, y1, x2, y2, x3, y3 float64) {
+	c.record("CubicTo", x1, y1, x2, y2, x3, y3)
+	c.Context.CubicTo(x1, y1, x2, y2, x3, y3)
+}
+
+// ClosePath adds a line back to the start of the current subpath.
+func (c *Context) ClosePath() {
+	c.record("ClosePath")
+	c.Context.ClosePath()
+}
+
+// ClearPath removes all points from the current path.
+func (c *Context) ClearPath() {
+	c.record("ClearPath")
+	c.Context.ClearPath()
+}
+
+// NewSubPath starts a new subpath without adding a line from the current point.
+func (c *Context) NewSubPath() {
+	c.record("NewSubPath")
+	c.Context.NewSubPath()
+}
+
+// DrawLine adds a line between two points to the current path.
+func (c *Context) DrawLine(x1, y1, x2, y2 float64) {
+	c.record("DrawLine", x1, y1, x2, y2)
+	c.Context.DrawLine(x1, y1, x2, y2)
+}
+
+// DrawRectangle adds a rectangle to the current path.
+func (c *Context) DrawRectangle(x, y, w, h float64) {
+	c.record("DrawRectangle", x, y, w, h)
+	c.Context.DrawRectangle(x, y, w, h)
+}
+
+// DrawRoundedRectangle adds a rectangle with rounded corners to the current path.
+func (c *Context) DrawRoundedRectangle(x, y, w, h, r float64) {
+	c.record("DrawRoundedRectangle", x, y, w, h, r)
+	c.Context.DrawRoundedRectangle(x, y, w, h, r)
+}
+
+// DrawCircle adds a circle to the current path.
+func (c *Context) DrawCircle(x, y, r float64) {
+	c.record("DrawCircle", x, y, r)
+	c.Context.DrawCircle(x, y, r)
+}
+
+// DrawArc adds an arc to the current path.
+func (c *Context) DrawArc(x, y, r, a1, a2 float64) {
+	c.record("DrawArc", x, y, r, a1, a2)
+	c.Context.DrawArc(x, y, r, a1, a2)
+}
+
+// DrawEllipse adds an ellipse to the current path.
+func (c *Context) DrawEllipse(x, y, rx, ry float64) {
+	c.record("DrawEllipse", x, y, rx, ry)
+	c.Context.DrawEllipse(x, y, rx, ry)
+}
+
+// DrawEllipticalArc adds an elliptical arc to the current path.
+func (c *Context) DrawEllipticalArc(x, y, rx, ry, a1, a2 float64) {
+	c.record("DrawEllipticalArc", x, y, rx, ry, a1, a2)
+	c.Context.DrawEllipticalArc(x, y, rx, ry, a1, a2)
+}
+
+// DrawRegularPolygon adds a regular polygon to the current path.
+func (c *Context) DrawRegularPolygon(n int, x, y, r, rotation float64) {
+	c.record("DrawRegularPolygon", float64(n), x, y, r, rotation)
+	c.Context.DrawRegularPolygon(n, x, y, r, rotation)
+}
+
+// Fill fills the current path and clears it.
+func (c *Context) Fill() {
+	c.record("Fill")
+	c.Context.Fill()
+}
+
+// FillPreserve fills the current path and keeps it.
+func (c *Context) FillPreserve() {
+	c.record("FillPreserve")
+	c.Context.FillPreserve()
+}
+
+// Stroke strokes the current path and clears it.
+func (c *Context) Stroke() {
+	c.record("Stroke")
+	c.Context.Stroke()
+}
+
+// StrokePreserve strokes the current path and keeps it.
+func (c *Context) StrokePreserve() {
+	c.record("StrokePreserve")
+	c.Context.StrokePreserve()
+}
+
+// Clip limits drawing to the current path and clears it.
+func (c *Context) Clip() {
+	c.record("Clip")
+	c.Context.Clip()
+}
+
+// ClipPreserve limits drawing to the current path and keeps it.
+func (c *Context) ClipPreserve() {
+	c.record("ClipPreserve")
+	c.Context.ClipPreserve()
+}
+
+// ResetClip removes the clip.
+func (c *Context) ResetClip() {
+	c.record("ResetClip")
+	c.Context.ResetClip()
+}
+
+// Clear fills the whole image with the current color.
+func (c *Context) Clear() {
+	c.record("Clear")
+	c.Context.Clear()
+}
+
+// SetPixel sets a single pixel, in pixel coordinates, to the current color.
+func (c *Context) SetPixel(x, y int) {
+	c.record("SetPixel", float64(x), float64(y))
+	c.Context.SetPixel(x, y)
+}
+
+// Push saves the transform, color and line settings. The current path and clip are not saved.
+func (c *Context) Push() {
+	c.record("Push")
+	c.Context.Push()
+}
+
+// Pop restores the settings saved by the last Push.
+func (c *Context) Pop() {
+	c.record("Pop")
+	c.Context.Pop()
+}
+
+// Translate moves the origin.
+func (c *Context) Translate(x, y float64) {
+	c.record("Translate", x, y)
+	c.Context.Translate(x, y)
+}
+
+// Rotate rotates the coordinate system around the origin, in radians.
+func (c *Context) Rotate(angle float64) {
+	c.record("Rotate", angle)
+	c.Context.Rotate(angle)
+}
+
+// RotateAbout rotates the coordinate system around a point, in radians.
+func (c *Context) RotateAbout(angle, x, y float64) {
+	c.record("RotateAbout", angle, x, y)
+	c.Context.RotateAbout(angle, x, y)
+}
+
+// Scale scales the coordinate system around the origin.
+func (c *Context) Scale(x, y float64) {
+	c.record("Scale", x, y)
+	c.Context.Scale(x, y)
+}
+
+// ScaleAbout scales the coordinate system around a point.
+func (c *Context) ScaleAbout(sx, sy, x, y float64) {
+	c.record("ScaleAbout", sx, sy, x, y)
+	c.Context.ScaleAbout(sx, sy, x, y)
+}
+
+// Shear shears the coordinate system around the origin.
+func (c *Context) Shear(x, y float64) {
+	c.record("Shear", x, y)
+	c.Context.Shear(x, y)
+}
+
+// InvertY flips the y axis so that y increases upwards from the bottom of the image.
+// On a scaled context it flips about the unscaled height, as the transform already includes the scale.
+func (c *Context) InvertY() {
+	c.record("InvertY")
+	_, h := c.Size()
+	c.Context.Translate(0, h)
+	c.Context.Scale(1, -1)
+}
+
+// SetRGBA sets the drawing color to the given rgba value.
+func (c *Context) SetRGBA(r, g, b, a float64) {
+	c.record("SetRGBA", r, g, b, a)
+	c.Context.SetRGBA(r, g, b, a)
+}
+
+// SetLineCap sets the shape of the ends of stroked lines.
+func (c *Context) SetLineCap(lineCap gg.LineCap) {
+	c.record("SetLineCap", float64(lineCap))
+	c.Context.SetLineCap(lineCap)
+}
+
+// SetLineCapRound gives stroked lines round ends.
+func (c *Context) SetLineCapRound() {
+	c.SetLineCap(gg.LineCapRound)
+}
+
+// SetLineCapButt gives stroked lines flat ends at their end points.
+func (c *Context) SetLineCapButt() {
+	c.SetLineCap(gg.LineCapButt)
+}
+
+// SetLineCapSquare gives stroked lines flat ends, extended past their end points by half the line width.
+func (c *Context) SetLineCapSquare() {
+	c.SetLineCap(gg.LineCapSquare)
+}
+
+// SetLineJoin sets the shape of the corners of stroked lines.
+func (c *Context) SetLineJoin(lineJoin gg.LineJoin) {
+	c.record("SetLineJoin", float64(lineJoin))
+	c.Context.SetLineJoin(lineJoin)
+}
+
+// SetLineJoinRound gives stroked lines round corners.
+func (c *Context) SetLineJoinRound() {
+	c.SetLineJoin(gg.LineJoinRound)
+}
+
+// SetLineJoinBevel gives stroked lines cut off corners.
+func (c *Context) SetLineJoinBevel() {
+	c.SetLineJoin(gg.LineJoinBevel)
+}
+
+// SetFillRule sets how overlapping parts of a path are filled.
+func (c *Context) SetFillRule(fillRule gg.FillRule) {
+	c.record("SetFillRule", float64(fillRule))
+	c.Context.SetFillRule(fillRule)
+}
+
+// SetFillRuleWinding fills every part of a path that is wound around.
+func (c *Context) SetFillRuleWinding() {
+	c.SetFillRule(gg.FillRuleWinding)
+}
+
+// SetFillRuleEvenOdd leaves holes where parts of a path overlap.
+func (c *Context) SetFillRuleEvenOdd() {
+	c.SetFillRule(gg.FillRuleEvenOdd)
+}
+
+// SetDash sets the lengths of alternating dashes and gaps for stroked lines, in unscaled units.
+// No lengths makes lines solid.
+func (c *Context) SetDash(dashes ...float64) {
+	c.record("SetDash", dashes...)
+	scaled := make([]float64, len(dashes))
+	for i, d := range dashes {
+		scaled[i] = d * c.scale
+	}
+	c.Context.SetDash(scaled...)
+}
+
+// SetDashOffset sets how far into the dash pattern lines start, in unscaled units.
+func (c *Context) SetDashOffset(offset float64) {
+	c.record("SetDashOffset", offset)
+	c.Context.SetDashOffset(offset * c.scale)
+}
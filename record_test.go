@@ -0,0 +1,128 @@
+package blgg
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// sketch draws a few pixels and a path, enough to exercise the recorded calls.
+func sketch(c *Context) {
+	c.ClearRGB(0.2, 0.4, 0.6)
+	c.SetRGB(1, 0, 0)
+	c.SetPixelF(3, 2)
+	c.SetRGBA(0, 1, 0, 0.5)
+	c.SetPixelF(5, 5)
+	// the stroke keeps clear of the pixels, so that scaling does not change their antialiasing.
+	c.SetLineWidth(1)
+	c.MoveTo(0, 4.5)
+	c.LineTo(2, 4.5)
+	c.Stroke()
+}
+
+func pixels(c *Context) []byte {
+	return c.Image().(*image.RGBA).Pix
+}
+
+func TestReplay(t *testing.T) {
+	direct := NewContext(8, 6)
+	direct.Record()
+	sketch(direct)
+	list := direct.StopRecording()
+	if direct.Recording() != nil {
+		t.Error("context still recording after StopRecording")
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded DisplayList
+	err = json.Unmarshal(data, &loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&loaded, list) {
+		t.Fatal("display list changed in a json round trip")
+	}
+
+	replayed := NewContext(8, 6)
+	err = loaded.Replay(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pixels(replayed), pixels(direct)) {
+		t.Error("replayed pixels differ from the direct draw")
+	}
+
+	scaled, err := loaded.Render(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := scaled.Width(), scaled.Height(); w != 16 || h != 12 {
+		t.Fatalf("rendered at %dx%d, want 16x12", w, h)
+	}
+	for _, p := range []image.Point{{6, 4}, {7, 5}} {
+		if got, want := scaled.Image().At(p.X, p.Y), direct.Image().At(3, 2); got != want {
+			t.Errorf("scaled pixel %v is %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestSaveJSON(t *testing.T) {
+	c := NewContext(8, 6)
+	c.Record()
+	sketch(c)
+	list := c.StopRecording()
+	path := filepath.Join(t.TempDir(), "list.json")
+	err := list.SaveJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDisplayList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := list.Diff(loaded); len(diff) != 0 || loaded.Width != 8 || loaded.Height != 6 {
+		t.Errorf("loaded list differs at %v", diff)
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	lists := map[string]*DisplayList{
+		"unknown op": {Width: 8, Height: 6, Ops: []Op{{"MoveTo", []float64{1, 1}}, {"Explode", nil}}},
+		"wrong args": {Width: 8, Height: 6, Ops: []Op{{"LineTo", []float64{1}}}},
+	}
+	for name, list := range lists {
+		if err := list.Replay(NewContext(8, 6)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	record := func(x float64, extra bool) *DisplayList {
+		c := NewContext(8, 6)
+		c.Record()
+		c.MoveTo(1, 1)
+		c.LineTo(x, 3)
+		c.Stroke()
+		if extra {
+			c.ClearPath()
+		}
+		return c.StopRecording()
+	}
+	a := record(7, false)
+	if diff := a.Diff(record(7, false)); len(diff) != 0 {
+		t.Errorf("identical lists differ at %v", diff)
+	}
+	if diff := a.Diff(record(6, false)); !reflect.DeepEqual(diff, []int{1}) {
+		t.Errorf("lists with one changed op differ at %v, want [1]", diff)
+	}
+	if diff := a.Diff(record(7, true)); !reflect.DeepEqual(diff, []int{3}) {
+		t.Errorf("lists with an extra op differ at %v, want [3]", diff)
+	}
+}
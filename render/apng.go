@@ -0,0 +1,328 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+)
+
+// APNGOptions holds the settings used when encoding an animated png.
+type APNGOptions struct {
+	Options
+	// FPS is the playback rate. Defaults to 30.
+	FPS float64
+	// LoopCount works as in GIFOptions: 0 loops forever, -1 plays once, n plays n+1 times.
+	LoopCount int
+	// Optimize encodes only the region that changed since the previous frame,
+	// and folds frames that did not change at all into the previous frame's delay.
+	Optimize bool
+}
+
+// apngFrame is a single encoded frame, held until the frame count is known.
+type apngFrame struct {
+	rect     image.Rectangle
+	delayNum uint16
+	data     []byte
+}
+
+// APNG renders a series of frames directly into an animated png, without external tools.
+// Unlike a gif, the output keeps full color and alpha.
+func APNG(width, height float64, numFrames int, path string, frameFunc FrameFunc, options APNGOptions) error {
+	if numFrames < 1 {
+		return &EncodeError{"apng", errors.New("an animated png needs at least one frame")}
+	}
+	delayNum, delayDen := apngDelay(options.FPS)
+	r := newRenderer(width, height, frameFunc, options.Options)
+	var frames []*apngFrame
+	var prev *image.NRGBA
+	for frame := 0; frame < numFrames; frame++ {
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		img := copyNRGBA(r.frame(percent, duration))
+
+		rect := img.Bounds()
+		if options.Optimize && prev != nil {
+			rect = changedRect(prev, img)
+			last := frames[len(frames)-1]
+			if rect.Empty() && int(last.delayNum)+int(delayNum) <= math.MaxUint16 {
+				last.delayNum += delayNum
+				continue
+			}
+			if rect.Empty() {
+				// the delay is full, so the frame starts again with a single unchanged pixel.
+				rect = image.Rect(0, 0, 1, 1)
+			}
+		}
+		data, err := compressPixels(img, rect)
+		if err != nil {
+			fmt.Println()
+			return &EncodeError{"apng", err}
+		}
+		frames = append(frames, &apngFrame{rect, delayNum, data})
+		prev = img
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	err = writeAPNG(file, int(width), int(height), frames, delayDen, apngPlays(options.LoopCount), pngText(options.Options, width, height))
+	if err != nil {
+		file.Close()
+		return &EncodeError{"apng", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	fmt.Println("\nDone!")
+	return nil
+}
+
+// apngDelay converts fps into a delay fraction. Whole frame rates are exact.
+// Rates too fast or too slow for 16 bit fractions are clamped to the nearest delay that fits.
+func apngDelay(fps float64) (uint16, uint16) {
+	if fps <= 0 {
+		fps = 30
+	}
+	if fps > math.MaxUint16 {
+		return 1, math.MaxUint16
+	}
+	if fps == math.Trunc(fps) || fps >= 1000 {
+		return 1, uint16(math.Round(fps))
+	}
+	// slow rates are given in coarser units so the numerator fits.
+	for den := 1000.0; den >= 1; den /= 10 {
+		num := math.Round(den / fps)
+		if num <= math.MaxUint16 {
+			return uint16(num), uint16(den)
+		}
+	}
+	return math.MaxUint16, 1
+}
+
+// apngPlays converts a gif style loop count into an apng play count.
+func apngPlays(loopCount int) uint32 {
+	if loopCount < 0 {
+		return 1
+	}
+	if loopCount == 0 {
+		return 0
+	}
+	return uint32(loopCount + 1)
+}
+
+// copyNRGBA copies an image into a new non premultiplied rgba image.
+func copyNRGBA(src image.Image) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	return dst
+}
+
+// changedRect returns the bounding rectangle of the pixels that differ between two images.
+func changedRect(a, b *image.NRGBA) image.Rectangle {
+	bounds := b.Bounds()
+	rect := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		rowA := a.Pix[a.PixOffset(bounds.Min.X, y):a.PixOffset(bounds.Max.X, y)]
+		rowB := b.Pix[b.PixOffset(bounds.Min.X, y):b.PixOffset(bounds.Max.X, y)]
+		if bytes.Equal(rowA, rowB) {
+			continue
+		}
+		x0, x1 := 0, len(rowB)/4-1
+		for bytes.Equal(rowA[x0*4:x0*4+4], rowB[x0*4:x0*4+4]) {
+			x0++
+		}
+		for bytes.Equal(rowA[x1*4:x1*4+4], rowB[x1*4:x1*4+4]) {
+			x1--
+		}
+		rect = rect.Union(image.Rect(bounds.Min.X+x0, y, bounds.Min.X+x1+1, y+1))
+	}
+	return rect
+}
+
+// compressPixels filters and deflates a region of an image as 8 bit rgba png data.
+func compressPixels(img *image.NRGBA, rect image.Rectangle) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	rowLen := rect.Dx() * 4
+	prev := make([]byte, rowLen)
+	filtered := make([][]byte, 5)
+	for i := range filtered {
+		filtered[i] = make([]byte, rowLen+1)
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)]
+		_, err := zw.Write(filterRow(row, prev, filtered))
+		if err != nil {
+			return nil, err
+		}
+		prev = row
+	}
+	err := zw.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// filterRow applies each png filter to a row and returns the one with the
+// smallest sum of absolute values, prefixed by its filter type.
+func filterRow(row, prev []byte, filtered [][]byte) []byte {
+	const bpp = 4
+	best, bestSum := 0, math.MaxInt
+	for f := 0; f < 5; f++ {
+		out := filtered[f]
+		out[0] = byte(f)
+		sum := 0
+		for i, x := range row {
+			var a, b, c byte
+			if i >= bpp {
+				a = row[i-bpp]
+				c = prev[i-bpp]
+			}
+			b = prev[i]
+			var v byte
+			switch f {
+			case 0:
+				v = x
+			case 1:
+				v = x - a
+			case 2:
+				v = x - b
+			case 3:
+				v = x - byte((int(a)+int(b))/2)
+			case 4:
+				v = x - paeth(a, b, c)
+			}
+			out[i+1] = v
+			if v < 128 {
+				sum += int(v)
+			} else {
+				sum += 256 - int(v)
+			}
+		}
+		if sum < bestSum {
+			best, bestSum = f, sum
+		}
+	}
+	return filtered[best]
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// writeAPNG writes the png signature, header, animation control, text and frame chunks.
+func writeAPNG(w io.Writer, width, height int, frames []*apngFrame, delayDen uint16, plays uint32, text map[string]string) error {
+	bw := bufio.NewWriter(w)
+	_, err := bw.Write(pngSignature)
+	if err != nil {
+		return err
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // color type: rgba
+	err = writeChunk(bw, "IHDR", ihdr)
+	if err != nil {
+		return err
+	}
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], plays)
+	err = writeChunk(bw, "acTL", actl)
+	if err != nil {
+		return err
+	}
+	err = writeText(bw, text)
+	if err != nil {
+		return err
+	}
+
+	seq := uint32(0)
+	for i, frame := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(frame.rect.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(frame.rect.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(frame.rect.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(frame.rect.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:], frame.delayNum)
+		binary.BigEndian.PutUint16(fctl[22:], delayDen)
+		// dispose op none and blend op source leave the rest of the canvas untouched
+		// and replace the frame region outright.
+		fctl[24] = 0
+		fctl[25] = 0
+		seq++
+		err = writeChunk(bw, "fcTL", fctl)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			err = writeChunk(bw, "IDAT", frame.data)
+		} else {
+			fdat := make([]byte, 4+len(frame.data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			copy(fdat[4:], frame.data)
+			seq++
+			err = writeChunk(bw, "fdAT", fdat)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeChunk(bw, "IEND", nil)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeChunk(w io.Writer, name string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		_, err := w.Write(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+// pngChunks returns the names of the chunks in png data, the acTL frame count if there is one,
+// and the delay numerator of each fcTL chunk.
+func pngChunks(t *testing.T, data []byte) ([]string, uint32, []uint16) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		t.Fatal("missing png signature")
+	}
+	var names []string
+	var frames uint32
+	var delays []uint16
+	data = data[len(pngSignature):]
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		name := string(data[4:8])
+		if name == "acTL" {
+			frames = binary.BigEndian.Uint32(data[8:])
+		}
+		if name == "fcTL" {
+			delays = append(delays, binary.BigEndian.Uint16(data[8+20:]))
+		}
+		names = append(names, name)
+		data = data[12+length:]
+	}
+	return names, frames, delays
+}
+
+func TestAPNG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	err := APNG(20, 10, 4, path, moving, APNGOptions{FPS: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// players without apng support show the first frame, which must be a valid png.
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 20 || size.Y != 10 {
+		t.Errorf("size %v, want 20x10", size)
+	}
+	names, frames, _ := pngChunks(t, data)
+	if frames != 4 {
+		t.Errorf("acTL has %d frames, want 4", frames)
+	}
+	counts := map[string]int{}
+	for _, name := range names {
+		counts[name]++
+	}
+	if counts["fcTL"] != 4 || counts["IDAT"] != 1 || counts["fdAT"] != 3 {
+		t.Errorf("chunks %v, want 4 fcTL, 1 IDAT and 3 fdAT", names)
+	}
+	if names[len(names)-1] != "IEND" {
+		t.Errorf("last chunk is %s, want IEND", names[len(names)-1])
+	}
+}
+
+func TestAPNGNoFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	err := APNG(20, 10, 0, path, moving, APNGOptions{})
+	if err == nil {
+		t.Error("no error for an animation without frames")
+	}
+}
+
+func TestAPNGLongDelay(t *testing.T) {
+	still := func(context *blgg.Context, width, height, percent float64) {
+		context.ClearBlack()
+	}
+	path := filepath.Join(t.TempDir(), "out.png")
+	// at 0.1 fps each frame lasts 10000/1000 seconds, so only 6 fit in one frame's delay.
+	err := APNG(2, 2, 8, path, still, APNGOptions{FPS: 0.1, Optimize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, frames, delays := pngChunks(t, data)
+	if frames != 2 || len(delays) != 2 || delays[0] != 60000 || delays[1] != 20000 {
+		t.Errorf("%d frames with delays %v, want 2 frames with delays [60000 20000]", frames, delays)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAPNGDelay(t *testing.T) {
+	tests := []struct {
+		fps      float64
+		num, den uint16
+	}{
+		{0, 1, 30},
+		{30, 1, 30},
+		{29.97, 33, 1000},
+		{65535, 1, 65535},
+		{70000, 1, 65535},
+		{1500.5, 1, 1501},
+		{0.1, 10000, 1000},
+		{0.01, 10000, 100},
+		{0.001, 10000, 10},
+		{0.00001, 65535, 1},
+	}
+	for _, test := range tests {
+		num, den := apngDelay(test.fps)
+		if num != test.num || den != test.den {
+			t.Errorf("apngDelay(%v) = %d/%d, want %d/%d", test.fps, num, den, test.num, test.den)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+)
+
+// AVIOptions holds the settings used when writing a Motion JPEG avi video.
+type AVIOptions struct {
+	Options
+	// FPS is the playback rate. Defaults to 30.
+	FPS float64
+	// Quality is the jpeg quality of each frame, from 1 to 100. Defaults to 90.
+	Quality int
+}
+
+// AVI renders a series of frames into a Motion JPEG avi video, without external tools.
+func AVI(width, height float64, numFrames int, path string, frameFunc FrameFunc, options AVIOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	avi, err := NewAVIWriter(file, int(width), int(height), options.FPS, options.Quality)
+	if err != nil {
+		file.Close()
+		return &EncodeError{"avi", err}
+	}
+	r := newRenderer(width, height, frameFunc, options.Options)
+	for frame := 0; frame < numFrames; frame++ {
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		err = avi.WriteFrame(r.frame(percent, duration))
+		if err != nil {
+			fmt.Println()
+			file.Close()
+			return &EncodeError{"avi", err}
+		}
+	}
+	err = avi.Close()
+	if err != nil {
+		file.Close()
+		return &EncodeError{"avi", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	fmt.Println("\nDone!")
+	return nil
+}
+
+// Offsets into the fixed size avi header that are filled in by Close.
+const (
+	aviRIFFSize       = 4
+	aviTotalFrames    = 48
+	aviMaxBytesPerSec = 36
+	aviSuggestedBuf   = 60
+	aviStreamLength   = 140
+	aviStreamBuf      = 144
+	aviMoviSize       = 216
+	aviMoviStart      = 220
+	aviHeaderSize     = 224
+)
+
+// aviIndexEntry records where a frame chunk was written, for the idx1 index.
+type aviIndexEntry struct {
+	offset uint32
+	size   uint32
+}
+
+// AVIWriter streams jpeg frames to a seekable writer as a Motion JPEG avi file.
+type AVIWriter struct {
+	w       io.WriteSeeker
+	fps     float64
+	quality int
+	pos     int64
+	index   []aviIndexEntry
+	maxSize uint32
+	buf     bytes.Buffer
+}
+
+// NewAVIWriter creates an AVIWriter and writes the avi header.
+// The header is completed when Close is called.
+func NewAVIWriter(w io.WriteSeeker, width, height int, fps float64, quality int) (*AVIWriter, error) {
+	if fps <= 0 {
+		fps = 30
+	}
+	if quality <= 0 {
+		quality = 90
+	}
+	a := &AVIWriter{w: w, fps: fps, quality: quality}
+	_, err := w.Write(a.header(width, height))
+	if err != nil {
+		return nil, err
+	}
+	a.pos = aviHeaderSize
+	return a, nil
+}
+
+// header builds the RIFF, hdrl and movi headers, with frame counts and sizes left at zero.
+func (a *AVIWriter) header(width, height int) []byte {
+	scale, rate := 1, int(a.fps)
+	if a.fps != math.Trunc(a.fps) {
+		scale, rate = 1000, int(math.Round(a.fps*1000))
+	}
+	var b bytes.Buffer
+	le := func(values ...interface{}) {
+		for _, v := range values {
+			binary.Write(&b, binary.LittleEndian, v)
+		}
+	}
+
+	b.WriteString("RIFF")
+	le(uint32(0))
+	b.WriteString("AVI LIST")
+	le(uint32(192))
+	b.WriteString("hdrlavih")
+	le(uint32(56))
+	le(uint32(math.Round(1e6/a.fps)), uint32(0), uint32(0), uint32(0x10)) // 0x10: has index
+	le(uint32(0), uint32(0), uint32(1), uint32(0))                        // frames, initial, streams, buffer
+	le(uint32(width), uint32(height), [4]uint32{})
+
+	b.WriteString("LIST")
+	le(uint32(116))
+	b.WriteString("strlstrh")
+	le(uint32(56))
+	b.WriteString("vidsMJPG")
+	le(uint32(0), uint16(0), uint16(0), uint32(0))
+	le(uint32(scale), uint32(rate), uint32(0), uint32(0), uint32(0))
+	le(int32(-1), uint32(0))
+	le(int16(0), int16(0), int16(width), int16(height))
+
+	b.WriteString("strf")
+	le(uint32(40))
+	le(uint32(40), int32(width), int32(height), uint16(1), uint16(24))
+	b.WriteString("MJPG")
+	le(uint32(width*height*3), int32(0), int32(0), uint32(0), uint32(0))
+
+	b.WriteString("LIST")
+	le(uint32(0))
+	b.WriteString("movi")
+	return b.Bytes()
+}
+
+// WriteFrame encodes an image as jpeg and writes it as the next frame.
+func (a *AVIWriter) WriteFrame(img image.Image) error {
+	a.buf.Reset()
+	err := jpeg.Encode(&a.buf, img, &jpeg.Options{Quality: a.quality})
+	if err != nil {
+		return err
+	}
+	size := uint32(a.buf.Len())
+	if size%2 == 1 {
+		a.buf.WriteByte(0)
+	}
+	err = a.writeChunk("00dc", size, a.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	a.index = append(a.index, aviIndexEntry{uint32(a.pos - aviMoviStart), size})
+	if size > a.maxSize {
+		a.maxSize = size
+	}
+	a.pos += 8 + int64(a.buf.Len())
+	return nil
+}
+
+func (a *AVIWriter) writeChunk(id string, size uint32, data []byte) error {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], size)
+	_, err := a.w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(data)
+	return err
+}
+
+// Close writes the index and fills in the frame counts and sizes in the header.
+// It does not close the underlying writer.
+func (a *AVIWriter) Close() error {
+	moviSize := uint32(a.pos - aviMoviStart)
+
+	var idx bytes.Buffer
+	for _, entry := range a.index {
+		idx.WriteString("00dc")
+		binary.Write(&idx, binary.LittleEndian, [3]uint32{0x10, entry.offset, entry.size}) // 0x10: key frame
+	}
+	err := a.writeChunk("idx1", uint32(idx.Len()), idx.Bytes())
+	if err != nil {
+		return err
+	}
+	a.pos += 8 + int64(idx.Len())
+
+	frames := uint32(len(a.index))
+	patches := []struct {
+		offset int64
+		value  uint32
+	}{
+		{aviRIFFSize, uint32(a.pos - 8)},
+		{aviMaxBytesPerSec, uint32(float64(a.maxSize) * a.fps)},
+		{aviTotalFrames, frames},
+		{aviSuggestedBuf, a.maxSize},
+		{aviStreamLength, frames},
+		{aviStreamBuf, a.maxSize},
+		{aviMoviSize, moviSize},
+	}
+	for _, p := range patches {
+		_, err = a.w.Seek(p.offset, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		err = binary.Write(a.w, binary.LittleEndian, p.value)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = a.w.Seek(a.pos, io.SeekStart)
+	return err
+}
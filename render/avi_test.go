@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAVIWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.avi")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	avi, err := NewAVIWriter(file, 3, 3, 30, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err = avi.WriteFrame(image.NewRGBA(image.Rect(0, 0, 3, 3)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = avi.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u32 := func(offset int) int {
+		return int(binary.LittleEndian.Uint32(data[offset:]))
+	}
+	id := func(offset int) string {
+		return string(data[offset : offset+4])
+	}
+	if id(0) != "RIFF" || id(8) != "AVI " || u32(4) != len(data)-8 {
+		t.Fatalf("RIFF header %q size %d, file is %d bytes", data[:12], u32(4), len(data))
+	}
+	// the hdrl list holds the avih chunk and the strl list, and ends where the movi list starts.
+	if id(12) != "LIST" || id(20) != "hdrl" || id(24) != "avih" || id(88) != "LIST" || id(96) != "strl" {
+		t.Fatalf("hdrl layout %q", data[12:100])
+	}
+	if 20+u32(16) != aviMoviSize-4 || 96+u32(92) != aviMoviSize-4 {
+		t.Errorf("hdrl size %d and strl size %d do not end at the movi list", u32(16), u32(92))
+	}
+	if u32(aviTotalFrames) != 2 || u32(aviStreamLength) != 2 {
+		t.Errorf("frame counts %d and %d, want 2", u32(aviTotalFrames), u32(aviStreamLength))
+	}
+
+	if id(aviMoviSize-4) != "LIST" || id(aviMoviStart) != "movi" {
+		t.Fatalf("movi header %q", data[aviMoviSize-4:aviHeaderSize])
+	}
+	moviEnd := aviMoviStart + u32(aviMoviSize)
+	var offsets []int
+	for pos := aviHeaderSize; pos < moviEnd; {
+		size := u32(pos + 4)
+		if id(pos) != "00dc" {
+			t.Fatalf("chunk %q at %d, want 00dc", id(pos), pos)
+		}
+		_, err := jpeg.Decode(bytes.NewReader(data[pos+8 : pos+8+size]))
+		if err != nil {
+			t.Errorf("frame at %d: %v", pos, err)
+		}
+		offsets = append(offsets, pos-aviMoviStart)
+		pos += 8 + size + size%2
+	}
+	if len(offsets) != 2 {
+		t.Fatalf("%d frames in movi, want 2", len(offsets))
+	}
+
+	// the index follows the movi list and points at each frame relative to the movi fourcc.
+	if id(moviEnd) != "idx1" || moviEnd+8+u32(moviEnd+4) != len(data) {
+		t.Fatalf("idx1 at %d is %q with size %d, file is %d bytes", moviEnd, id(moviEnd), u32(moviEnd+4), len(data))
+	}
+	for i, offset := range offsets {
+		entry := moviEnd + 8 + i*16
+		if id(entry) != "00dc" || u32(entry+8) != offset {
+			t.Errorf("index entry %d points at %d, want %d", i, u32(entry+8), offset)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// MissingToolError is returned when an external tool is not installed.
+type MissingToolError struct {
+	Tool string
+}
+
+func (e *MissingToolError) Error() string {
+	return fmt.Sprintf("render: %s not found in PATH", e.Tool)
+}
+
+// ToolError is returned when an external tool runs but fails.
+// Stderr holds whatever the tool wrote to its standard error.
+type ToolError struct {
+	Tool   string
+	Stderr string
+	Err    error
+}
+
+func (e *ToolError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("render: %s failed: %s", e.Tool, e.Err)
+	}
+	return fmt.Sprintf("render: %s failed: %s: %s", e.Tool, e.Err, e.Stderr)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError is returned when an image or animation can not be encoded.
+type EncodeError struct {
+	Format string
+	Err    error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("render: encoding %s: %s", e.Format, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// FileError is returned when reading or writing the file system fails.
+type FileError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("render: %s %s: %s", e.Op, e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// runTool runs an external tool, capturing its stderr for the error.
+func runTool(tool string, args ...string) error {
+	if _, err := exec.LookPath(tool); err != nil {
+		return &MissingToolError{tool}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(tool, args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return &ToolError{tool, strings.TrimSpace(stderr.String()), err}
+	}
+	return nil
+}
+
+// savePNG writes an image to a png file.
+// The image is written to a temporary file first, so an interrupted render
+// never leaves a truncated png behind.
+func savePNG(path string, img image.Image, text map[string]string) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return &FileError{"create", tmp, err}
+	}
+	err = encodePNG(file, img, text)
+	if err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return &EncodeError{"png", err}
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(tmp)
+		return &FileError{"close", tmp, err}
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return &FileError{"rename", path, err}
+	}
+	return nil
+}
+
+// loadPNG reads an image from a png file.
+func loadPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &FileError{"open", path, err}
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, &FileError{"decode", path, err}
+	}
+	return img, nil
+}
+
+// resetDir removes a directory and everything in it, then creates it again.
+func resetDir(dir string) error {
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return &FileError{"remove", dir, err}
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return &FileError{"mkdir", dir, err}
+	}
+	return nil
+}
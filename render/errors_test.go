@@ -0,0 +1,66 @@
+package render
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestMissingTool(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	dir := t.TempDir()
+	err := ConvertToVideo(dir, filepath.Join(dir, "out.mp4"), YoutubePreset)
+	var missing *MissingToolError
+	if !errors.As(err, &missing) {
+		t.Fatalf("got error %v, want a MissingToolError", err)
+	}
+	if missing.Tool != "ffmpeg" {
+		t.Errorf("missing tool is %q, want ffmpeg", missing.Tool)
+	}
+}
+
+func TestToolErrorUnwrap(t *testing.T) {
+	err := runTool("sh", "-c", "echo broken >&2; exit 3")
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("got error %v, want a ToolError", err)
+	}
+	if toolErr.Stderr != "broken" {
+		t.Errorf("stderr is %q, want broken", toolErr.Stderr)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("ToolError does not unwrap to the exit status, got %v", err)
+	}
+}
+
+func TestFileErrorUnwrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "out.png")
+	err := Image(20, 10, path, moving, 0, Options{})
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("got error %v, want a FileError", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("FileError does not unwrap to fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestEncodeErrorUnwrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "text.png")
+	err := os.WriteFile(path, []byte("not a png"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadMetadata(path)
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Fatalf("got error %v, want an EncodeError", err)
+	}
+	if errors.Unwrap(err) != encodeErr.Err || encodeErr.Err == nil {
+		t.Error("EncodeError does not unwrap to its cause")
+	}
+}
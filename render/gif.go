@@ -0,0 +1,237 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GIFOptions holds the settings used when encoding an animated gif.
+type GIFOptions struct {
+	Options
+	// FPS is the playback rate. Frame delays are derived from it. Defaults to 30.
+	FPS float64
+	// LoopCount follows image/gif: 0 loops forever, -1 plays once, n plays n+1 times.
+	LoopCount int
+	// Quantizer is the palette algorithm, MedianCut or Octree.
+	Quantizer int
+	// GlobalPalette uses one palette for the whole animation instead of one per frame.
+	GlobalPalette bool
+	// Dither applies Floyd-Steinberg dithering when mapping frames to the palette.
+	Dither bool
+	// Optimize makes pixels that did not change since the previous frame transparent
+	// and crops each frame to the area that changed.
+	Optimize bool
+}
+
+// GIF renders a series of frames directly into an animated gif, without external tools.
+func GIF(width, height float64, numFrames int, path string, frameFunc FrameFunc, options GIFOptions) error {
+	r := newRenderer(width, height, frameFunc, options.Options)
+	images := make([]*image.RGBA, numFrames)
+	for frame := 0; frame < numFrames; frame++ {
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		images[frame] = copyRGBA(r.frame(percent, duration))
+	}
+	fmt.Println("\nEncoding...")
+	err := writeGIF(path, images, options)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Done!")
+	return nil
+}
+
+// GoToGIF converts a folder of pngs into an animated gif using the built in encoder.
+func GoToGIF(folder, outFileName string, fps float64) error {
+	paths, err := filepath.Glob(filepath.Join(folder, "*.png"))
+	if err != nil {
+		return &FileError{"glob", folder, err}
+	}
+	sort.Strings(paths)
+	images := make([]*image.RGBA, len(paths))
+	for i, path := range paths {
+		img, err := loadPNG(path)
+		if err != nil {
+			return err
+		}
+		images[i] = copyRGBA(img)
+	}
+	return writeGIF(outFileName, images, GIFOptions{FPS: fps})
+}
+
+func writeGIF(path string, images []*image.RGBA, options GIFOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	err = gif.EncodeAll(file, encodeGIF(images, options))
+	if err != nil {
+		file.Close()
+		return &EncodeError{"gif", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	return nil
+}
+
+// copyRGBA copies an image into a new rgba image, so a context can be reused.
+func copyRGBA(src image.Image) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	return dst
+}
+
+// gifDelays converts fps into per frame delays in 100ths of a second.
+// The rounding error is carried forward so the total duration stays accurate.
+func gifDelays(numFrames int, fps float64) []int {
+	if fps <= 0 {
+		fps = 30
+	}
+	delays := make([]int, numFrames)
+	for i := range delays {
+		start := math.Round(float64(i) * 100 / fps)
+		end := math.Round(float64(i+1) * 100 / fps)
+		delays[i] = int(end - start)
+	}
+	return delays
+}
+
+func encodeGIF(images []*image.RGBA, options GIFOptions) *gif.GIF {
+	g := &gif.GIF{
+		LoopCount: options.LoopCount,
+		Delay:     gifDelays(len(images), options.FPS),
+	}
+	if len(images) == 0 {
+		return g
+	}
+
+	// one slot is kept free for the transparent color when optimizing.
+	numColors := 256
+	if options.Optimize {
+		numColors = 255
+	}
+
+	var global color.Palette
+	if options.GlobalPalette {
+		h := histogram{}
+		for _, img := range images {
+			h.add(img)
+		}
+		global = withTransparent(h.quantize(options.Quantizer, numColors), options.Optimize)
+		g.Config = image.Config{
+			ColorModel: global,
+			Width:      images[0].Bounds().Dx(),
+			Height:     images[0].Bounds().Dy(),
+		}
+	}
+
+	var shown []color.Color
+	for _, img := range images {
+		palette := global
+		if palette == nil {
+			h := histogram{}
+			h.add(img)
+			palette = withTransparent(h.quantize(options.Quantizer, numColors), options.Optimize)
+		}
+		frame := mapToPalette(img, palette, options.Dither)
+		disposal := byte(0)
+		if options.Optimize {
+			frame, shown = diffFrame(frame, shown)
+			disposal = gif.DisposalNone
+		}
+		g.Image = append(g.Image, frame)
+		g.Disposal = append(g.Disposal, disposal)
+	}
+	return g
+}
+
+// withTransparent appends a fully transparent color to a palette if needed.
+func withTransparent(palette color.Palette, transparent bool) color.Palette {
+	if transparent {
+		palette = append(palette, color.RGBA{})
+	}
+	return palette
+}
+
+// mapToPalette converts an rgba image to a paletted image.
+func mapToPalette(img *image.RGBA, palette color.Palette, dither bool) *image.Paletted {
+	b := img.Bounds()
+	opaque := palette
+	if _, _, _, a := palette[len(palette)-1].RGBA(); a == 0 {
+		opaque = palette[:len(palette)-1]
+	}
+	p := image.NewPaletted(b, palette)
+	if dither {
+		// the dithered image uses the opaque colors only, which share their indexes with palette.
+		d := image.NewPaletted(b, opaque)
+		draw.FloydSteinberg.Draw(d, b, img, b.Min)
+		copy(p.Pix, d.Pix)
+		return p
+	}
+	cache := map[uint32]uint8{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		j := p.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl := img.Pix[i], img.Pix[i+1], img.Pix[i+2]
+			key := rgbKey(r, g, bl)
+			index, ok := cache[key]
+			if !ok {
+				index = uint8(opaque.Index(color.RGBA{r, g, bl, 255}))
+				cache[key] = index
+			}
+			p.Pix[j] = index
+			i += 4
+			j++
+		}
+	}
+	return p
+}
+
+// diffFrame replaces pixels that match what is already on screen with the transparent
+// index and crops the frame to the changed area. shown holds the displayed colors
+// and is updated. The palette's last color must be transparent.
+func diffFrame(frame *image.Paletted, shown []color.Color) (*image.Paletted, []color.Color) {
+	b := frame.Bounds()
+	transparent := uint8(len(frame.Palette) - 1)
+	if shown == nil {
+		shown = make([]color.Color, b.Dx()*b.Dy())
+	}
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X-1, b.Min.Y-1
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := frame.PixOffset(x, y)
+			c := frame.Palette[frame.Pix[i]]
+			k := (y-b.Min.Y)*b.Dx() + (x - b.Min.X)
+			if shown[k] == c {
+				frame.Pix[i] = transparent
+				continue
+			}
+			shown[k] = c
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			maxY = y
+		}
+	}
+	if maxX < minX {
+		// gif frames can not be empty, so a single transparent pixel stands in.
+		minX, minY, maxX, maxY = b.Min.X, b.Min.Y, b.Min.X, b.Min.Y
+	}
+	return frame.SubImage(image.Rect(minX, minY, maxX+1, maxY+1)).(*image.Paletted), shown
+}
@@ -0,0 +1,47 @@
+package render
+
+import (
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+// moving draws a pixel that crosses the frame once per loop.
+func moving(context *blgg.Context, width, height, percent float64) {
+	context.ClearBlack()
+	context.SetWhite()
+	context.SetPixelF(percent*width, 0)
+}
+
+func TestGIF(t *testing.T) {
+	for _, optimize := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "out.gif")
+		err := GIF(20, 10, 4, path, moving, GIFOptions{FPS: 10, Optimize: optimize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := gif.DecodeAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("optimize %v: %v", optimize, err)
+		}
+		if len(g.Image) != 4 {
+			t.Errorf("optimize %v: %d frames, want 4", optimize, len(g.Image))
+		}
+		if g.Config.Width != 20 || g.Config.Height != 10 {
+			t.Errorf("optimize %v: size %dx%d, want 20x10", optimize, g.Config.Width, g.Config.Height)
+		}
+		for i, delay := range g.Delay {
+			if delay != 10 {
+				t.Errorf("optimize %v: frame %d delay %d, want 10", optimize, i, delay)
+			}
+		}
+	}
+}
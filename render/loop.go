@@ -0,0 +1,62 @@
+package render
+
+import "fmt"
+
+// LoopCheck reports how closely the end of an animation matches its start.
+type LoopCheck struct {
+	// Mean is the average difference of every channel of every pixel, from 0 to 1.
+	Mean float64
+	// Max is the largest difference of any one channel, from 0 to 1.
+	Max float64
+	// Changed is the number of pixels that differ at all.
+	Changed int
+	// Pixels is the number of pixels compared.
+	Pixels int
+}
+
+// Seamless reports whether no channel differs by more than tolerance, from 0 to 1.
+// A tolerance of 1.0/255 allows for rounding.
+func (l LoopCheck) Seamless(tolerance float64) bool {
+	return l.Max <= tolerance
+}
+
+// String summarises the check in one line.
+func (l LoopCheck) String() string {
+	return fmt.Sprintf("mean difference %.4f, max %.4f, %d of %d pixels changed", l.Mean, l.Max, l.Changed, l.Pixels)
+}
+
+// CheckLoop renders the frames at percent 0 and percent 1 and compares them.
+// In a seamless loop they are the same picture, so any difference shows as a jump
+// each time a LoopRepeat animation wraps around. Motion blur is not applied.
+func CheckLoop(width, height float64, frameFunc FrameFunc, options Options) LoopCheck {
+	options.MotionBlur = MotionBlur{}
+	r := newRenderer(width, height, frameFunc, options)
+	first := copyRGBA(r.frame(0, 0))
+	last := copyRGBA(r.frame(1, 0))
+
+	check := LoopCheck{Pixels: len(first.Pix) / 4}
+	total := 0
+	for i := 0; i < len(first.Pix); i += 4 {
+		changed := false
+		for c := 0; c < 4; c++ {
+			d := int(first.Pix[i+c]) - int(last.Pix[i+c])
+			if d < 0 {
+				d = -d
+			}
+			if d > 0 {
+				changed = true
+			}
+			if float64(d)/255 > check.Max {
+				check.Max = float64(d) / 255
+			}
+			total += d
+		}
+		if changed {
+			check.Changed++
+		}
+	}
+	if len(first.Pix) > 0 {
+		check.Mean = float64(total) / 255 / float64(len(first.Pix))
+	}
+	return check
+}
@@ -0,0 +1,230 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Metadata holds the details written into png output when set on Options.
+// The seed, size, percent and time of the render are added automatically.
+type Metadata struct {
+	// Sketch is the name of the sketch that produced the image.
+	Sketch string
+	// Values are extra key/values to embed, such as parameter settings.
+	// Keys must be 1 to 79 characters long.
+	Values map[string]string
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Keywords of the text chunks written for a render.
+const (
+	MetaSoftware = "Software"
+	MetaSketch   = "Sketch"
+	MetaSeed     = "Seed"
+	MetaPercent  = "Percent"
+	MetaWidth    = "Width"
+	MetaHeight   = "Height"
+	MetaTime     = "Creation Time"
+)
+
+// pngText returns the text chunks for an image rendered with options, or nil if it has no Metadata.
+func pngText(options Options, width, height float64) map[string]string {
+	if options.Metadata == nil {
+		return nil
+	}
+	text := map[string]string{}
+	for key, value := range options.Metadata.Values {
+		text[key] = value
+	}
+	text[MetaSoftware] = "blgg"
+	if options.Metadata.Sketch != "" {
+		text[MetaSketch] = options.Metadata.Sketch
+	}
+	if options.Seed != 0 {
+		text[MetaSeed] = strconv.FormatInt(options.Seed, 10)
+	}
+	text[MetaWidth] = strconv.Itoa(int(width))
+	text[MetaHeight] = strconv.Itoa(int(height))
+	text[MetaTime] = time.Now().Format(time.RFC3339)
+	return text
+}
+
+// frameText returns the text chunks for a single frame at percent, or nil if options has no Metadata.
+func frameText(options Options, width, height, percent float64) map[string]string {
+	text := pngText(options, width, height)
+	if text != nil {
+		text[MetaPercent] = strconv.FormatFloat(percent, 'f', -1, 64)
+	}
+	return text
+}
+
+// encodePNG encodes img as a png with the given text chunks placed before the image data.
+func encodePNG(w io.Writer, img image.Image, text map[string]string) error {
+	if len(text) == 0 {
+		return png.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return err
+	}
+	// the signature and IHDR chunk always come first and have a fixed size.
+	data := buf.Bytes()
+	head := len(pngSignature) + 8 + 13 + 4
+	_, err = w.Write(data[:head])
+	if err != nil {
+		return err
+	}
+
+	err = writeText(w, text)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data[head:])
+	return err
+}
+
+// writeText writes text chunks in keyword order.
+// Values that are not plain ascii are written as utf-8 iTXt chunks.
+func writeText(w io.Writer, text map[string]string) error {
+	keys := make([]string, 0, len(text))
+	for key := range text {
+		if len(key) < 1 || len(key) > 79 {
+			return errors.New("png text keyword must be 1 to 79 characters: " + strconv.Quote(key))
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := text[key]
+		var err error
+		if isPlainText(value) {
+			err = writeChunk(w, "tEXt", []byte(key+"\x00"+value))
+		} else {
+			// uncompressed, with empty language tag and translated keyword.
+			err = writeChunk(w, "iTXt", []byte(key+"\x00\x00\x00\x00\x00"+value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isPlainText reports whether s is printable ascii, which reads the same as Latin-1 in a tEXt chunk.
+func isPlainText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 || (s[i] < 0x20 && s[i] != '\n') {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadMetadata returns the text chunks of a png file, such as those written for Options.Metadata.
+// tEXt, zTXt and iTXt chunks are all read. Numbers are returned as text, see the Meta keywords.
+func ReadMetadata(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &FileError{"read", path, err}
+	}
+	text, err := parseText(data)
+	if err != nil {
+		return nil, &EncodeError{"png", err}
+	}
+	return text, nil
+}
+
+// parseText walks the chunks of png data and collects the text chunks.
+func parseText(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a png file")
+	}
+	text := map[string]string{}
+	data = data[len(pngSignature):]
+	for len(data) >= 12 {
+		size := int(binary.BigEndian.Uint32(data))
+		if size > len(data)-12 {
+			return nil, errors.New("truncated chunk")
+		}
+		name := string(data[4:8])
+		chunk := data[8 : 8+size]
+		data = data[12+size:]
+
+		switch name {
+		case "tEXt":
+			key, value, ok := cutNull(chunk)
+			if ok {
+				text[key] = latin1ToUTF8(value)
+			}
+		case "zTXt":
+			key, rest, ok := cutNull(chunk)
+			if ok && len(rest) > 0 {
+				value, err := inflate([]byte(rest[1:]))
+				if err != nil {
+					return nil, err
+				}
+				text[key] = latin1ToUTF8(value)
+			}
+		case "iTXt":
+			key, rest, ok := cutNull(chunk)
+			if !ok || len(rest) < 2 {
+				continue
+			}
+			compressed := rest[0] == 1
+			_, rest, _ = cutNull([]byte(rest[2:])) // language tag
+			_, value, ok := cutNull([]byte(rest))  // translated keyword
+			if !ok {
+				continue
+			}
+			if compressed {
+				var err error
+				value, err = inflate([]byte(value))
+				if err != nil {
+					return nil, err
+				}
+			}
+			text[key] = value
+		case "IEND":
+			return text, nil
+		}
+	}
+	return text, nil
+}
+
+// cutNull splits b around its first null byte.
+func cutNull(b []byte) (string, string, bool) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", "", false
+	}
+	return string(b[:i]), string(b[i+1:]), true
+}
+
+func inflate(b []byte) (string, error) {
+	r, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	return string(out), err
+}
+
+func latin1ToUTF8(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadMetadata(t *testing.T) {
+	options := Options{
+		Seed:     42,
+		Metadata: &Metadata{Sketch: "moving", Values: map[string]string{"speed": "fast", "note": "über"}},
+	}
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	err := Image(20, 10, image, moving, 0.25, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apng := filepath.Join(dir, "anim.png")
+	err = APNG(20, 10, 2, apng, moving, APNGOptions{Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		MetaSoftware: "blgg",
+		MetaSketch:   "moving",
+		MetaSeed:     "42",
+		MetaWidth:    "20",
+		MetaHeight:   "10",
+		"speed":      "fast",
+		"note":       "über",
+	}
+	for _, path := range []string{image, apng} {
+		text, err := ReadMetadata(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range want {
+			if text[key] != value {
+				t.Errorf("%s: %s is %q, want %q", filepath.Base(path), key, text[key], value)
+			}
+		}
+		if text[MetaTime] == "" {
+			t.Errorf("%s: missing %s", filepath.Base(path), MetaTime)
+		}
+	}
+	text, _ := ReadMetadata(image)
+	if text[MetaPercent] != "0.25" {
+		t.Errorf("image: %s is %q, want 0.25", MetaPercent, text[MetaPercent])
+	}
+}
@@ -0,0 +1,400 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// plotSnap is the distance in millimeters within which points are treated as one point when optimizing.
+const plotSnap = 0.01
+
+// plotAngle is the difference in direction in radians within which segments are treated as parallel.
+const plotAngle = 1e-4
+
+// PlotStats reports what optimizing a plot changed. Distances are in millimeters.
+type PlotStats struct {
+	// LinesBefore and LinesAfter are the number of pen down strokes.
+	LinesBefore, LinesAfter int
+	// DrawnBefore and DrawnAfter are the pen down distances. Removing overlaps shortens it.
+	DrawnBefore, DrawnAfter float64
+	// TravelBefore and TravelAfter are the pen up distances.
+	TravelBefore, TravelAfter float64
+}
+
+// String summarises the stats in one line.
+func (s PlotStats) String() string {
+	return fmt.Sprintf("lines %d -> %d, drawn %.1fmm -> %.1fmm, travel %.1fmm -> %.1fmm",
+		s.LinesBefore, s.LinesAfter, s.DrawnBefore, s.DrawnAfter, s.TravelBefore, s.TravelAfter)
+}
+
+// Drawn returns the total pen down distance of the plot in millimeters.
+func (p *PenPlot) Drawn() float64 {
+	total := 0.0
+	for _, layer := range p.Layers {
+		for _, line := range layer.Lines {
+			for i := 1; i < len(line); i++ {
+				total += distance(line[i-1], line[i])
+			}
+		}
+	}
+	return total
+}
+
+// Travel returns the total pen up distance of the plot in millimeters.
+// Each layer starts from home at the bottom left of the paper.
+func (p *PenPlot) Travel() float64 {
+	total := 0.0
+	for _, layer := range p.Layers {
+		total += travel(layer.Lines, p.home())
+	}
+	return total
+}
+
+// Optimize rewrites each layer to draw the same marks with less pen up travel.
+// Duplicate segments and overlapping parts of collinear segments are drawn once,
+// segments that share endpoints are joined into longer lines, and the lines are
+// ordered and reversed, nearest first and then improved with 2-opt.
+func (p *PenPlot) Optimize() PlotStats {
+	stats := PlotStats{DrawnBefore: p.Drawn(), TravelBefore: p.Travel()}
+	for _, layer := range p.Layers {
+		stats.LinesBefore += len(layer.Lines)
+		g := &plotGraph{cells: map[[2]int][]int{}}
+		for _, line := range layer.Lines {
+			g.addLine(line)
+		}
+		g.mergeCollinear()
+		layer.Lines = orderLines(g.join(), p.home())
+		stats.LinesAfter += len(layer.Lines)
+	}
+	stats.DrawnAfter = p.Drawn()
+	stats.TravelAfter = p.Travel()
+	return stats
+}
+
+// home is where the pen starts, the plotter's origin at the bottom left of the paper.
+func (p *PenPlot) home() geom.Point {
+	return geom.Point{X: 0, Y: p.Height}
+}
+
+// plotGraph holds segments between shared points, so that lines can be joined where they meet.
+type plotGraph struct {
+	points []geom.Point
+	// cells indexes points by a grid of plotSnap squares.
+	cells map[[2]int][]int
+	edges [][2]int
+}
+
+// node returns the index of the point within plotSnap of p, adding p if there is none.
+func (g *plotGraph) node(p geom.Point) int {
+	cx, cy := int(math.Floor(p.X/plotSnap)), int(math.Floor(p.Y/plotSnap))
+	for x := cx - 1; x <= cx+1; x++ {
+		for y := cy - 1; y <= cy+1; y++ {
+			for _, i := range g.cells[[2]int{x, y}] {
+				if distance(g.points[i], p) <= plotSnap {
+					return i
+				}
+			}
+		}
+	}
+	g.points = append(g.points, p)
+	g.cells[[2]int{cx, cy}] = append(g.cells[[2]int{cx, cy}], len(g.points)-1)
+	return len(g.points) - 1
+}
+
+// addLine adds each segment of a polyline, dropping any that have no length.
+func (g *plotGraph) addLine(line []geom.Point) {
+	prev := g.node(line[0])
+	for _, p := range line[1:] {
+		n := g.node(p)
+		if n != prev {
+			g.edges = append(g.edges, [2]int{prev, n})
+		}
+		prev = n
+	}
+}
+
+// mergeCollinear replaces segments that lie along the same line with the pieces between their endpoints
+// that any of them cover, so duplicates and overlaps are drawn once and every endpoint is kept for joining.
+func (g *plotGraph) mergeCollinear() {
+	type parallel struct {
+		edge  [2]int
+		angle float64
+	}
+	edges := make([]parallel, len(g.edges))
+	for i, e := range g.edges {
+		a, b := g.points[e[0]], g.points[e[1]]
+		// directions are compared modulo pi, with nearly horizontal lines either side of zero.
+		angle := math.Atan2(b.Y-a.Y, b.X-a.X)
+		if angle < 0 {
+			angle += math.Pi
+		}
+		if angle >= math.Pi-plotAngle {
+			angle -= math.Pi
+		}
+		edges[i] = parallel{e, angle}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].angle < edges[j].angle })
+
+	var merged [][2]int
+	for start := 0; start < len(edges); {
+		end := start + 1
+		for end < len(edges) && edges[end].angle-edges[end-1].angle <= plotAngle {
+			end++
+		}
+		group := make([][2]int, end-start)
+		for i := range group {
+			group[i] = edges[start+i].edge
+		}
+		merged = append(merged, g.mergeParallel(group)...)
+		start = end
+	}
+	g.edges = merged
+}
+
+// mergeParallel merges a group of parallel segments, sorting them into lines by their offset.
+func (g *plotGraph) mergeParallel(edges [][2]int) [][2]int {
+	a, b := g.points[edges[0][0]], g.points[edges[0][1]]
+	length := distance(a, b)
+	dir := geom.Point{X: (b.X - a.X) / length, Y: (b.Y - a.Y) / length}
+	along := func(n int) float64 {
+		return g.points[n].X*dir.X + g.points[n].Y*dir.Y
+	}
+	across := func(n int) float64 {
+		return g.points[n].Y*dir.X - g.points[n].X*dir.Y
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return across(edges[i][0])+across(edges[i][1]) < across(edges[j][0])+across(edges[j][1])
+	})
+
+	var merged [][2]int
+	for start := 0; start < len(edges); {
+		offset := (across(edges[start][0]) + across(edges[start][1])) / 2
+		type span struct {
+			t0, t1 float64
+		}
+		type stop struct {
+			t    float64
+			node int
+		}
+		var spans []span
+		var stops []stop
+		end := start
+		for ; end < len(edges); end++ {
+			e := edges[end]
+			if (across(e[0])+across(e[1]))/2-offset > plotSnap {
+				break
+			}
+			if math.Abs(across(e[0])-offset) > plotSnap || math.Abs(across(e[1])-offset) > plotSnap {
+				// not quite on the line, so it is kept as it is.
+				merged = append(merged, e)
+				continue
+			}
+			t0, t1 := along(e[0]), along(e[1])
+			spans = append(spans, span{math.Min(t0, t1), math.Max(t0, t1)})
+			stops = append(stops, stop{t0, e[0]}, stop{t1, e[1]})
+		}
+		start = end
+
+		sort.Slice(spans, func(i, j int) bool { return spans[i].t0 < spans[j].t0 })
+		sort.Slice(stops, func(i, j int) bool { return stops[i].t < stops[j].t })
+		next, covered := 0, math.Inf(-1)
+		for i := 0; i+1 < len(stops); i++ {
+			s0, s1 := stops[i], stops[i+1]
+			if s0.node == s1.node {
+				continue
+			}
+			mid := (s0.t + s1.t) / 2
+			for next < len(spans) && spans[next].t0 <= mid {
+				covered = math.Max(covered, spans[next].t1)
+				next++
+			}
+			if covered >= mid {
+				merged = append(merged, [2]int{s0.node, s1.node})
+			}
+		}
+	}
+	return merged
+}
+
+// join chains the segments into polylines. Lines start from points with an odd number of segments,
+// where a line has to end anyway, and at each point carry on along the straightest unused segment.
+func (g *plotGraph) join() [][]geom.Point {
+	adjacent := make([][]int, len(g.points))
+	for i, e := range g.edges {
+		adjacent[e[0]] = append(adjacent[e[0]], i)
+		adjacent[e[1]] = append(adjacent[e[1]], i)
+	}
+	used := make([]bool, len(g.edges))
+	other := func(edge, node int) int {
+		if g.edges[edge][0] == node {
+			return g.edges[edge][1]
+		}
+		return g.edges[edge][0]
+	}
+
+	var lines [][]geom.Point
+	walk := func(node int) {
+		line := []geom.Point{g.points[node]}
+		prev := -1
+		for {
+			best, bestTurn := -1, math.Inf(1)
+			for _, edge := range adjacent[node] {
+				if used[edge] {
+					continue
+				}
+				turn := 0.0
+				if prev >= 0 {
+					p0, p1, p2 := g.points[prev], g.points[node], g.points[other(edge, node)]
+					ax, ay := p1.X-p0.X, p1.Y-p0.Y
+					bx, by := p2.X-p1.X, p2.Y-p1.Y
+					turn = math.Abs(math.Atan2(ax*by-ay*bx, ax*bx+ay*by))
+				}
+				if turn < bestTurn {
+					best, bestTurn = edge, turn
+				}
+			}
+			if best < 0 {
+				break
+			}
+			used[best] = true
+			prev, node = node, other(best, node)
+			line = append(line, g.points[node])
+		}
+		if len(line) > 1 {
+			lines = append(lines, line)
+		}
+	}
+	unused := func(node int) bool {
+		for _, edge := range adjacent[node] {
+			if !used[edge] {
+				return true
+			}
+		}
+		return false
+	}
+	for node := range adjacent {
+		if len(adjacent[node])%2 == 1 {
+			for unused(node) {
+				walk(node)
+			}
+		}
+	}
+	for node := range adjacent {
+		for unused(node) {
+			walk(node)
+		}
+	}
+	return lines
+}
+
+// orderLines orders lines and sets their direction to shorten the pen up travel from home.
+// Each line goes to the one whose start or end is nearest, and closed lines may start at any point.
+// The order is then improved with 2-opt, reversing runs of lines while that shortens the travel.
+func orderLines(lines [][]geom.Point, home geom.Point) [][]geom.Point {
+	done := make([]bool, len(lines))
+	order := make([][]geom.Point, 0, len(lines))
+	pos := home
+	for len(order) < len(lines) {
+		best, bestPoint, bestDist := -1, 0, math.Inf(1)
+		for i, line := range lines {
+			if done[i] {
+				continue
+			}
+			last := len(line) - 1
+			if line[0] == line[last] {
+				for j, p := range line[:last] {
+					if d := distance(pos, p); d < bestDist {
+						best, bestPoint, bestDist = i, j, d
+					}
+				}
+				continue
+			}
+			if d := distance(pos, line[0]); d < bestDist {
+				best, bestPoint, bestDist = i, 0, d
+			}
+			if d := distance(pos, line[last]); d < bestDist {
+				best, bestPoint, bestDist = i, last, d
+			}
+		}
+		done[best] = true
+		line := lines[best]
+		last := len(line) - 1
+		switch {
+		case bestPoint == 0:
+		case line[0] == line[last]:
+			// a closed line is rotated to start and end at the chosen point.
+			line = append(append([]geom.Point{}, line[bestPoint:]...), line[1:bestPoint+1]...)
+		default:
+			reverseLine(line)
+		}
+		order = append(order, line)
+		pos = line[len(line)-1]
+	}
+
+	start := func(i int) geom.Point {
+		if i < 0 {
+			return home
+		}
+		return order[i][0]
+	}
+	end := func(i int) geom.Point {
+		if i < 0 {
+			return home
+		}
+		return order[i][len(order[i])-1]
+	}
+	for pass := 0; pass < 100; pass++ {
+		improved := false
+		for i := -1; i < len(order)-1; i++ {
+			for j := i + 1; j < len(order); j++ {
+				// reversing lines i+1 to j joins the end of i to the end of j, and the start of i+1 to the start of j+1.
+				before := distance(end(i), start(i+1))
+				after := distance(end(i), end(j))
+				if j+1 < len(order) {
+					before += distance(end(j), start(j+1))
+					after += distance(start(i+1), start(j+1))
+				}
+				if after < before-1e-9 {
+					for a, b := i+1, j; a < b; a, b = a+1, b-1 {
+						order[a], order[b] = order[b], order[a]
+					}
+					for k := i + 1; k <= j; k++ {
+						reverseLine(order[k])
+					}
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return order
+}
+
+// travel returns the pen up distance to draw lines in order, starting from home.
+func travel(lines [][]geom.Point, home geom.Point) float64 {
+	total := 0.0
+	pos := home
+	for _, line := range lines {
+		total += distance(pos, line[0])
+		pos = line[len(line)-1]
+	}
+	return total
+}
+
+// reverseLine reverses a polyline in place.
+func reverseLine(line []geom.Point) {
+	for a, b := 0, len(line)-1; a < b; a, b = a+1, b-1 {
+		line[a], line[b] = line[b], line[a]
+	}
+}
+
+// distance returns the distance between two points.
+func distance(a, b geom.Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	return math.Sqrt(dx*dx + dy*dy)
+}
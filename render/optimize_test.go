@@ -0,0 +1,95 @@
+package render
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// segments returns every drawn segment of a plot, each with its endpoints in a fixed order, sorted.
+func segments(p *PenPlot) [][4]float64 {
+	var all [][4]float64
+	round := func(v float64) float64 {
+		return math.Round(v*1e6) / 1e6
+	}
+	for _, layer := range p.Layers {
+		for _, line := range layer.Lines {
+			for i := 1; i < len(line); i++ {
+				a, b := line[i-1], line[i]
+				if b.X < a.X || (b.X == a.X && b.Y < a.Y) {
+					a, b = b, a
+				}
+				all = append(all, [4]float64{round(a.X), round(a.Y), round(b.X), round(b.Y)})
+			}
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		for k := 0; k < 4; k++ {
+			if all[i][k] != all[j][k] {
+				return all[i][k] < all[j][k]
+			}
+		}
+		return false
+	})
+	return all
+}
+
+func plotLine(points ...float64) []geom.Point {
+	var line []geom.Point
+	for i := 0; i < len(points); i += 2 {
+		line = append(line, geom.Point{X: points[i], Y: points[i+1]})
+	}
+	return line
+}
+
+func TestOptimizeKeepsSegments(t *testing.T) {
+	// the sides of a square, out of order and some reversed, and a line far from it.
+	plot := &PenPlot{Width: 100, Height: 100, Layers: []*PenLayer{{Lines: [][]geom.Point{
+		plotLine(10, 10, 20, 10),
+		plotLine(80, 80, 90, 90),
+		plotLine(10, 20, 20, 20),
+		plotLine(20, 10, 20, 20),
+		plotLine(10, 20, 10, 10),
+	}}}}
+	before := segments(plot)
+	stats := plot.Optimize()
+	if !reflect.DeepEqual(segments(plot), before) {
+		t.Errorf("segments changed from %v to %v", before, segments(plot))
+	}
+	if math.Abs(stats.DrawnAfter-stats.DrawnBefore) > 1e-9 {
+		t.Errorf("drawn %v -> %v, want it kept", stats.DrawnBefore, stats.DrawnAfter)
+	}
+	if stats.LinesBefore != 5 || stats.LinesAfter != 2 {
+		t.Errorf("lines %d -> %d, want the square joined into one: 5 -> 2", stats.LinesBefore, stats.LinesAfter)
+	}
+	if stats.TravelAfter > stats.TravelBefore {
+		t.Errorf("travel grew from %v to %v", stats.TravelBefore, stats.TravelAfter)
+	}
+}
+
+func TestOptimizeOverlap(t *testing.T) {
+	// two overlapping collinear lines and a duplicate of one of them.
+	plot := &PenPlot{Width: 100, Height: 100, Layers: []*PenLayer{{Lines: [][]geom.Point{
+		plotLine(0, 0, 10, 0),
+		plotLine(15, 0, 5, 0),
+		plotLine(0, 0, 10, 0),
+	}}}}
+	stats := plot.Optimize()
+	if stats.DrawnBefore != 30 || math.Abs(stats.DrawnAfter-15) > 1e-9 {
+		t.Errorf("drawn %v -> %v, want 30 -> 15", stats.DrawnBefore, stats.DrawnAfter)
+	}
+	if stats.LinesAfter != 1 {
+		t.Fatalf("%d lines after, want 1", stats.LinesAfter)
+	}
+	run := plot.Layers[0].Lines[0]
+	first, last := run[0], run[len(run)-1]
+	if first.X > last.X {
+		first, last = last, first
+	}
+	if first != (geom.Point{X: 0, Y: 0}) || last != (geom.Point{X: 15, Y: 0}) {
+		t.Errorf("merged line runs from %v to %v, want (0, 0) to (15, 0)", first, last)
+	}
+}
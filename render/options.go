@@ -0,0 +1,111 @@
+package render
+
+import "math"
+
+const (
+	// LoopRepeat ends each animation just before percent 1, so the last frame flows back into the first.
+	LoopRepeat = iota
+	// LoopOnce plays from percent 0 to exactly 1, for animations that do not loop.
+	LoopOnce
+	// LoopPingPong plays from percent 0 up to 1 and back again.
+	LoopPingPong
+)
+
+// Options holds settings shared by the frame based render functions.
+// The zero value renders each frame once, exactly as FrameFunc draws it.
+type Options struct {
+	// MotionBlur averages several sub frames into each frame.
+	MotionBlur MotionBlur
+	// Supersample renders each frame at this many times the output size and scales it
+	// back down, smoothing thin lines and small details. 0 or 1 disables it.
+	// FrameFunc still receives the output width and height.
+	Supersample int
+	// Filter is used to scale supersampled frames down, BoxFilter or LanczosFilter.
+	Filter int
+	// Seed makes rendering reproducible. Each frame's context is seeded with a value
+	// derived from Seed and the frame's percent, so frames match however and in
+	// whatever order they are rendered. 0 leaves the context on the global random.
+	Seed int64
+	// Metadata, if set, is embedded in png output along with the seed, size, percent
+	// and time of the render. Use ReadMetadata to read it back.
+	Metadata *Metadata
+	// Loop is how the frames of an animation map onto percents, LoopRepeat, LoopOnce or LoopPingPong.
+	Loop int
+}
+
+// frameTime returns the percent of a frame, and the length of one frame as a percent, for the loop mode.
+func (o Options) frameTime(frame, numFrames int) (percent, duration float64) {
+	switch o.Loop {
+	case LoopOnce:
+		if numFrames < 2 {
+			return 0, 1
+		}
+		duration = 1 / float64(numFrames-1)
+		return float64(frame) * duration, duration
+	case LoopPingPong:
+		duration = 2 / float64(numFrames)
+		percent = float64(frame) * duration
+		if percent > 1 {
+			percent = 2 - percent
+		}
+		return percent, duration
+	}
+	duration = 1 / float64(numFrames)
+	return float64(frame) * duration, duration
+}
+
+// wrap brings a percent that has strayed outside 0 to 1, such as a motion blur sample,
+// back into range in the way the loop mode plays.
+func (o Options) wrap(percent float64) float64 {
+	switch o.Loop {
+	case LoopOnce:
+		return math.Max(0, math.Min(1, percent))
+	case LoopPingPong:
+		percent = math.Abs(percent)
+		if percent > 1 {
+			percent = 2 - percent
+		}
+		return percent
+	}
+	return percent - math.Floor(percent)
+}
+
+// FrameSeed returns the seed the context is given for the frame at percent.
+// Percent 1 is seeded like percent 0, as they are the same moment of a loop.
+func FrameSeed(seed int64, percent float64) int64 {
+	if percent == 1 {
+		percent = 0
+	}
+	// splitmix64, to spread nearby percents across unrelated seeds.
+	z := uint64(seed) ^ math.Float64bits(percent)
+	z += 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// MotionBlur describes temporal supersampling. Each output frame is the average
+// of Samples renders spread across the time the shutter is open.
+type MotionBlur struct {
+	// Samples is the number of sub frames per frame. 0 or 1 disables blur.
+	Samples int
+	// Shutter is the shutter angle in degrees. 360 keeps the shutter open for
+	// the whole frame interval, 180 for half of it. Defaults to 180.
+	Shutter float64
+	// Jitter places each sample at a random time within its slot instead of
+	// spacing the samples evenly, trading banding for noise. The times follow Options.Seed when it is set.
+	Jitter bool
+}
+
+// enabled reports whether more than one sample is taken per frame.
+func (m MotionBlur) enabled() bool {
+	return m.Samples > 1
+}
+
+// open returns the fraction of the frame interval the shutter is open for.
+func (m MotionBlur) open() float64 {
+	if m.Shutter <= 0 {
+		return 0.5
+	}
+	return m.Shutter / 360
+}
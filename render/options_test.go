@@ -0,0 +1,79 @@
+package render
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestFrameTime(t *testing.T) {
+	tests := []struct {
+		loop     int
+		percents []float64
+		duration float64
+	}{
+		{LoopRepeat, []float64{0, 0.25, 0.5, 0.75}, 0.25},
+		{LoopOnce, []float64{0, 1.0 / 3, 2.0 / 3, 1}, 1.0 / 3},
+		{LoopPingPong, []float64{0, 0.5, 1, 0.5}, 0.5},
+	}
+	for _, test := range tests {
+		options := Options{Loop: test.loop}
+		for frame, want := range test.percents {
+			percent, duration := options.frameTime(frame, 4)
+			if math.Abs(percent-want) > 1e-9 || math.Abs(duration-test.duration) > 1e-9 {
+				t.Errorf("loop %d frame %d at %v for %v, want %v for %v", test.loop, frame, percent, duration, want, test.duration)
+			}
+		}
+	}
+}
+
+func TestFrameTimeWrapsToStart(t *testing.T) {
+	// the frame after the last one is the first frame again in the looping modes.
+	for _, loop := range []int{LoopRepeat, LoopPingPong} {
+		options := Options{Loop: loop}
+		next, _ := options.frameTime(4, 4)
+		first, _ := options.frameTime(0, 4)
+		if options.wrap(next) != first {
+			t.Errorf("loop %d: frame 4 wraps to %v, want %v", loop, options.wrap(next), first)
+		}
+	}
+	if FrameSeed(7, 1) != FrameSeed(7, 0) {
+		t.Error("percent 1 is not seeded like percent 0")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		loop          int
+		percent, want float64
+	}{
+		{LoopRepeat, -0.25, 0.75},
+		{LoopRepeat, 1.25, 0.25},
+		{LoopOnce, -0.25, 0},
+		{LoopOnce, 1.25, 1},
+		{LoopPingPong, -0.25, 0.25},
+		{LoopPingPong, 1.25, 0.75},
+	}
+	for _, test := range tests {
+		if got := (Options{Loop: test.loop}).wrap(test.percent); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("loop %d wraps %v to %v, want %v", test.loop, test.percent, got, test.want)
+		}
+	}
+}
+
+func TestCheckLoop(t *testing.T) {
+	// moving draws its pixel off the frame at percent 1, so the last moment does not match the first.
+	check := CheckLoop(20, 10, moving, Options{})
+	if check.Seamless(1.0/255) || check.Changed != 1 || check.Max != 1 || check.Pixels != 200 {
+		t.Errorf("mismatched loop reported as %s", check)
+	}
+
+	looping := func(context *blgg.Context, width, height, percent float64) {
+		moving(context, width, height, math.Mod(percent, 1))
+	}
+	check = CheckLoop(20, 10, looping, Options{})
+	if !check.Seamless(0) || check.Changed != 0 || check.Mean != 0 {
+		t.Errorf("seamless loop reported as %s", check)
+	}
+}
@@ -0,0 +1,382 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/bit101/bitlib/geom"
+	"github.com/bit101/blgg"
+	"github.com/fogleman/gg"
+)
+
+// PageSize is the size of a page in points, 72 to the inch.
+type PageSize struct {
+	Width, Height float64
+}
+
+// Common page sizes, in portrait orientation.
+var (
+	PageA3      = PageSize{841.89, 1190.55}
+	PageA4      = PageSize{595.28, 841.89}
+	PageA5      = PageSize{419.53, 595.28}
+	PageLetter  = PageSize{612, 792}
+	PageLegal   = PageSize{612, 1008}
+	PageTabloid = PageSize{792, 1224}
+)
+
+// PageSizeMM returns a page size given in millimeters.
+func PageSizeMM(width, height float64) PageSize {
+	return PageSize{width * 72 / 25.4, height * 72 / 25.4}
+}
+
+// Landscape returns the page size with its longer side horizontal.
+func (p PageSize) Landscape() PageSize {
+	if p.Width < p.Height {
+		return PageSize{p.Height, p.Width}
+	}
+	return p
+}
+
+// PDFOptions holds the settings used when writing pdf files.
+type PDFOptions struct {
+	Options
+	// Page is the size of each page. If it is zero, each page is the size of the frame at DPI.
+	// Otherwise the frame is scaled to fit inside the margin and centered.
+	Page PageSize
+	// DPI is the number of frame units per inch when Page is zero. Defaults to 72, one unit per point.
+	DPI float64
+	// Margin is the space left around the frame on a sized page, in points.
+	Margin float64
+	// Percents, if set, renders one page for each percent instead of evenly spaced pages.
+	Percents []float64
+}
+
+// PDF renders frames into a vector pdf document, one page per frame, without external tools.
+// Paths, fills, strokes, transforms, line settings, colors and clipping are kept as vectors.
+// Text and images are not recorded. Motion blur and supersampling do not apply.
+func PDF(width, height float64, numPages int, path string, frameFunc FrameFunc, options PDFOptions) error {
+	percents := options.Percents
+	if len(percents) == 0 {
+		for page := 0; page < numPages; page++ {
+			percent, _ := options.frameTime(page, numPages)
+			percents = append(percents, percent)
+		}
+	}
+	r := newRenderer(width, height, frameFunc, Options{Seed: options.Seed})
+	lists := make([]*blgg.DisplayList, len(percents))
+	for i, percent := range percents {
+		lists[i] = r.record(percent)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	err = WritePDF(file, lists, options)
+	if err != nil {
+		file.Close()
+		return &EncodeError{"pdf", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	return nil
+}
+
+// WritePDF writes display lists as the pages of a pdf document, laid out by the page settings in options.
+// If options has Metadata, it is written into the document information.
+func WritePDF(w io.Writer, lists []*blgg.DisplayList, options PDFOptions) error {
+	pw := &pdfWriter{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1 to 3 are the catalog, page tree and info, then each page and its contents.
+	pw.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(lists))
+	for i := range lists {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	pw.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(lists)))
+	pw.object(3, pdfInfo(options, lists))
+
+	for i, list := range lists {
+		page, transform := pdfLayout(list, options)
+		content, alphas, err := pdfContent(list, transform)
+		if err != nil {
+			return err
+		}
+		var states []string
+		for _, a := range alphas {
+			states = append(states, fmt.Sprintf("/GS%s << /ca %s /CA %s >>", pdfAlphaName(a), vectorNum(a), vectorNum(a)))
+		}
+		pw.object(4+i*2, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Contents %d 0 R /Resources << /ExtGState << %s >> >> >>",
+			vectorNum(page.Width), vectorNum(page.Height), 5+i*2, strings.Join(states, " ")))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		_, err = zw.Write(content)
+		if err != nil {
+			return err
+		}
+		err = zw.Close()
+		if err != nil {
+			return err
+		}
+		pw.stream(5+i*2, compressed.Bytes())
+	}
+	return pw.finish()
+}
+
+// pdfLayout returns the page size and the cm transform that places a list on it with y pointing down.
+func pdfLayout(list *blgg.DisplayList, options PDFOptions) (PageSize, string) {
+	page, scale, x, y := fitPage(list.Width, list.Height, options.Page, options.DPI, options.Margin)
+	// flip y, with the frame's top left at x, y from the page's top left.
+	transform := fmt.Sprintf("%s 0 0 %s %s %s cm", vectorNum(scale), vectorNum(-scale), vectorNum(x), vectorNum(page.Height-y))
+	return page, transform
+}
+
+// fitPage places a frame on a page, returning the page size, the points per frame unit and
+// the frame's top left from the page's top left. A zero page is the size of the frame at dpi,
+// otherwise the frame is scaled to fit inside the margin and centered.
+func fitPage(width, height float64, page PageSize, dpi, margin float64) (PageSize, float64, float64, float64) {
+	if page.Width <= 0 || page.Height <= 0 {
+		if dpi <= 0 {
+			dpi = 72
+		}
+		scale := 72 / dpi
+		return PageSize{width * scale, height * scale}, scale, 0, 0
+	}
+	availW := page.Width - margin*2
+	availH := page.Height - margin*2
+	scale := math.Min(availW/width, availH/height)
+	return page, scale, (page.Width - width*scale) / 2, (page.Height - height*scale) / 2
+}
+
+// pdfContent builds the content stream for a display list and returns the alphas it uses.
+func pdfContent(list *blgg.DisplayList, transform string) ([]byte, []float64, error) {
+	shapes, err := list.Shapes()
+	if err != nil {
+		return nil, nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString("q " + transform + "\n")
+
+	alphas := map[float64]bool{}
+	// each clip is a graphics state level. open holds the clips of the levels currently open.
+	var open []*blgg.Clip
+	for _, shape := range shapes {
+		if shape.Color.A <= 0 {
+			continue
+		}
+		same := 0
+		for same < len(open) && same < len(shape.Clip) && open[same] == shape.Clip[same] {
+			same++
+		}
+		for len(open) > same {
+			b.WriteString("Q\n")
+			open = open[:len(open)-1]
+		}
+		for _, clip := range shape.Clip[same:] {
+			b.WriteString("q ")
+			pdfPath(&b, clip.Path)
+			if clip.FillRule == gg.FillRuleEvenOdd {
+				b.WriteString("W* n\n")
+			} else {
+				b.WriteString("W n\n")
+			}
+			open = append(open, clip)
+		}
+
+		b.WriteString("q ")
+		r, g, bl := pdfColor(shape.Color.R), pdfColor(shape.Color.G), pdfColor(shape.Color.B)
+		alpha := math.Min(1, shape.Color.A)
+		if alpha < 1 {
+			alpha = math.Round(alpha*1000) / 1000
+			alphas[alpha] = true
+			fmt.Fprintf(&b, "/GS%s gs ", pdfAlphaName(alpha))
+		}
+		if shape.Stroke {
+			fmt.Fprintf(&b, "%s %s %s RG %s w %d J %d j ", r, g, bl, vectorNum(shape.LineWidth), pdfCap(shape.LineCap), pdfJoin(shape.LineJoin))
+			if len(shape.Dash) > 0 {
+				dashes := make([]string, len(shape.Dash))
+				for i, d := range shape.Dash {
+					dashes[i] = vectorNum(d)
+				}
+				fmt.Fprintf(&b, "[%s] %s d ", strings.Join(dashes, " "), vectorNum(shape.DashOffset))
+			}
+		} else {
+			fmt.Fprintf(&b, "%s %s %s rg ", r, g, bl)
+		}
+		pdfPath(&b, shape.Path)
+		switch {
+		case shape.Stroke:
+			b.WriteString("S Q\n")
+		case shape.FillRule == gg.FillRuleEvenOdd:
+			b.WriteString("f* Q\n")
+		default:
+			b.WriteString("f Q\n")
+		}
+	}
+	for range open {
+		b.WriteString("Q\n")
+	}
+	b.WriteString("Q\n")
+
+	sorted := make([]float64, 0, len(alphas))
+	for a := range alphas {
+		sorted = append(sorted, a)
+	}
+	sort.Float64s(sorted)
+	return b.Bytes(), sorted, nil
+}
+
+// pdfPath writes path construction operators. Quadratic curves are raised to cubics, which pdf uses.
+func pdfPath(b *bytes.Buffer, path blgg.Path) {
+	var start, current geom.Point
+	for _, s := range path.Segments {
+		switch s.Kind {
+		case blgg.SegmentMove:
+			fmt.Fprintf(b, "%s %s m ", vectorNum(s.Points[0].X), vectorNum(s.Points[0].Y))
+			start = s.Points[0]
+		case blgg.SegmentLine:
+			fmt.Fprintf(b, "%s %s l ", vectorNum(s.Points[0].X), vectorNum(s.Points[0].Y))
+		case blgg.SegmentQuad:
+			q, end := s.Points[0], s.Points[1]
+			c1 := geom.Point{X: current.X + (q.X-current.X)*2/3, Y: current.Y + (q.Y-current.Y)*2/3}
+			c2 := geom.Point{X: end.X + (q.X-end.X)*2/3, Y: end.Y + (q.Y-end.Y)*2/3}
+			fmt.Fprintf(b, "%s %s %s %s %s %s c ", vectorNum(c1.X), vectorNum(c1.Y), vectorNum(c2.X), vectorNum(c2.Y), vectorNum(end.X), vectorNum(end.Y))
+		case blgg.SegmentCubic:
+			p := s.Points
+			fmt.Fprintf(b, "%s %s %s %s %s %s c ", vectorNum(p[0].X), vectorNum(p[0].Y), vectorNum(p[1].X), vectorNum(p[1].Y), vectorNum(p[2].X), vectorNum(p[2].Y))
+		case blgg.SegmentClose:
+			b.WriteString("h ")
+			current = start
+			continue
+		}
+		current, _ = s.End()
+	}
+}
+
+// pdfInfo builds the document information dictionary.
+func pdfInfo(options PDFOptions, lists []*blgg.DisplayList) string {
+	info := "<< /Producer (blgg)"
+	if options.Metadata == nil || len(lists) == 0 {
+		return info + " >>"
+	}
+	text := pngText(options.Options, lists[0].Width, lists[0].Height)
+	delete(text, MetaSoftware)
+	delete(text, MetaTime)
+	if sketch, ok := text[MetaSketch]; ok {
+		info += " /Title " + pdfString(sketch)
+		delete(text, MetaSketch)
+	}
+	keys := make([]string, 0, len(text))
+	for key := range text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		info += " /" + pdfName(key) + " " + pdfString(text[key])
+	}
+	return info + " >>"
+}
+
+func pdfCap(lineCap gg.LineCap) int {
+	switch lineCap {
+	case gg.LineCapButt:
+		return 0
+	case gg.LineCapSquare:
+		return 2
+	}
+	return 1
+}
+
+func pdfJoin(lineJoin gg.LineJoin) int {
+	if lineJoin == gg.LineJoinBevel {
+		return 2
+	}
+	return 1
+}
+
+func pdfColor(v float64) string {
+	return vectorNum(math.Max(0, math.Min(1, v)))
+}
+
+// pdfAlphaName turns an alpha into part of a resource name, 0.5 becoming 500.
+func pdfAlphaName(a float64) string {
+	return strconv.Itoa(int(math.Round(a * 1000)))
+}
+
+// pdfName escapes a string for use as a pdf name.
+func pdfName(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte("#()<>[]{}/%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02x", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// pdfString writes a string as a pdf literal, or as utf-16 hex if it is not plain ascii.
+func pdfString(s string) string {
+	if isPlainText(s) {
+		r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+		return "(" + r.Replace(s) + ")"
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfWriter writes numbered objects and keeps their offsets for the cross reference table.
+type pdfWriter struct {
+	w       *bufio.Writer
+	pos     int
+	offsets map[int]int
+}
+
+func (p *pdfWriter) printf(format string, args ...interface{}) {
+	n, _ := fmt.Fprintf(p.w, format, args...)
+	p.pos += n
+}
+
+func (p *pdfWriter) object(id int, body string) {
+	if p.offsets == nil {
+		p.offsets = map[int]int{}
+	}
+	p.offsets[id] = p.pos
+	p.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (p *pdfWriter) stream(id int, data []byte) {
+	p.object(id, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(data), data))
+}
+
+// finish writes the cross reference table and trailer.
+func (p *pdfWriter) finish() error {
+	xref := p.pos
+	count := len(p.offsets) + 1
+	p.printf("xref\n0 %d\n0000000000 65535 f \n", count)
+	for id := 1; id < count; id++ {
+		p.printf("%010d 00000 n \n", p.offsets[id])
+	}
+	p.printf("trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", count, xref)
+	return p.w.Flush()
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+// diagonal strokes a line across the frame.
+func diagonal(context *blgg.Context, width, height, percent float64) {
+	context.MoveTo(10, 10)
+	context.LineTo(width-10, 10+percent*(height-20))
+	context.Stroke()
+}
+
+func TestWritePDF(t *testing.T) {
+	r := newRenderer(100, 100, diagonal, Options{})
+	lists := []*blgg.DisplayList{r.record(0), r.record(0.5)}
+	var b bytes.Buffer
+	err := WritePDF(&b, lists, PDFOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) {
+		t.Fatal("missing pdf header")
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing end of file marker")
+	}
+
+	// startxref points at the cross reference table, whose entries point at each object.
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 8\n")) {
+		t.Fatalf("startxref %d does not point at an xref table for 8 objects", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != 7 {
+		t.Fatalf("%d xref entries, want 7", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("xref entry %d does not point at object %d", i+1, i+1)
+		}
+	}
+	if !bytes.Contains(data, []byte("/Type /Pages /Kids [4 0 R 6 0 R] /Count 2")) {
+		t.Error("page tree does not list both pages")
+	}
+	if !bytes.Contains(data, []byte("/MediaBox [0 0 100 100]")) {
+		t.Error("pages are not the size of the frame")
+	}
+
+	// the first page's content draws the line.
+	start := bytes.Index(data, []byte("stream\n")) + len("stream\n")
+	zr, err := zlib.NewReader(bytes.NewReader(data[start:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"10 10 m", "90 10 l", "S"} {
+		if !bytes.Contains(content, []byte(op)) {
+			t.Errorf("page content %q does not contain %q", content, op)
+		}
+	}
+}
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWritePDFErrors(t *testing.T) {
+	r := newRenderer(100, 100, diagonal, Options{})
+	err := WritePDF(failWriter{}, []*blgg.DisplayList{r.record(0)}, PDFOptions{})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("writing to a failing writer gave %v", err)
+	}
+	bad := &blgg.DisplayList{Width: 100, Height: 100, Ops: []blgg.Op{{Name: "Explode"}}}
+	err = WritePDF(io.Discard, []*blgg.DisplayList{bad}, PDFOptions{})
+	if err == nil {
+		t.Error("no error for an unknown op")
+	}
+}
@@ -0,0 +1,347 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/geom"
+	"github.com/bit101/blgg"
+	"github.com/fogleman/gg"
+)
+
+// Plotter file formats.
+const (
+	// HPGL writes Hewlett-Packard Graphics Language, read by most pen plotters and cutters.
+	HPGL = iota
+	// GCode writes G-code in millimeters, for plotters driven by grbl or similar firmware.
+	GCode
+)
+
+// Default G-code pen commands, for a plotter that lifts the pen on its z axis.
+const (
+	GCodePenUp   = "G0 Z5"
+	GCodePenDown = "G0 Z0"
+)
+
+// PlotOptions holds the settings used when writing plotter files.
+type PlotOptions struct {
+	Options
+	// Format is HPGL or GCode.
+	Format int
+	// Paper is the size of the paper. If it is zero, the paper is the size of the frame at DPI.
+	// Otherwise the frame is scaled to fit inside the margin and centered.
+	Paper PageSize
+	// DPI is the number of frame units per inch when Paper is zero. Defaults to 72, one unit per point.
+	DPI float64
+	// Margin is the space left around the frame on sized paper, in points.
+	Margin float64
+	// Tolerance is how far, in millimeters, a flattened curve may stray from the true curve. Defaults to 0.1.
+	Tolerance float64
+	// PenUp and PenDown are the G-code commands that lift and lower the pen. They default to GCodePenUp and GCodePenDown.
+	PenUp, PenDown string
+	// Feed is the G-code drawing speed in millimeters per minute. Defaults to 3000. Pen up moves go at full speed.
+	Feed float64
+	// Optimize removes overlapping strokes and reorders lines to shorten pen up travel. See PenPlot.Optimize.
+	Optimize bool
+}
+
+// PenPlot is the stroked geometry of a frame, flattened into lines for a pen plotter.
+// Positions are in millimeters from the top left of the paper.
+type PenPlot struct {
+	// Width and Height are the size of the paper in millimeters.
+	Width, Height float64
+	// Layers holds one layer for each stroke color, in the order the colors were first used.
+	Layers []*PenLayer
+}
+
+// PenLayer is the lines drawn with one pen.
+type PenLayer struct {
+	Color blcolor.Color
+	Lines [][]geom.Point
+}
+
+// Plot renders a single frame as an HPGL or G-code file for a pen plotter.
+// Only strokes are plotted, each color with its own pen. Fills and line widths are ignored,
+// while dashes and clipping are applied to the lines. Text and images are not recorded. Motion blur and supersampling do not apply.
+// If the plot is optimized, the returned stats report what changed. Otherwise they are zero.
+func Plot(width, height float64, path string, frameFunc FrameFunc, percent float64, options PlotOptions) (PlotStats, error) {
+	r := newRenderer(width, height, frameFunc, Options{Seed: options.Seed})
+	file, err := os.Create(path)
+	if err != nil {
+		return PlotStats{}, &FileError{"create", path, err}
+	}
+	stats, err := WritePlot(file, r.record(percent), options)
+	if err != nil {
+		file.Close()
+		return PlotStats{}, &EncodeError{"plot", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return PlotStats{}, &FileError{"close", path, err}
+	}
+	return stats, nil
+}
+
+// WritePlot writes a display list as plotter commands in the format and paper size set in options.
+// If the plot is optimized, the returned stats report what changed. Otherwise they are zero.
+func WritePlot(w io.Writer, list *blgg.DisplayList, options PlotOptions) (PlotStats, error) {
+	plot, err := NewPenPlot(list, options)
+	if err != nil {
+		return PlotStats{}, err
+	}
+	var stats PlotStats
+	if options.Optimize {
+		stats = plot.Optimize()
+	}
+	return stats, plot.Write(w, options)
+}
+
+// NewPenPlot flattens the strokes in a display list into lines on the paper set in options.
+func NewPenPlot(list *blgg.DisplayList, options PlotOptions) (*PenPlot, error) {
+	shapes, err := list.Shapes()
+	if err != nil {
+		return nil, err
+	}
+	page, scale, x, y := fitPage(list.Width, list.Height, options.Paper, options.DPI, options.Margin)
+	mm := 25.4 / 72
+	tolerance := options.Tolerance
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+	// curves are flattened in frame units, so the tolerance is scaled down to them.
+	tolerance /= scale * mm
+
+	plot := &PenPlot{Width: page.Width * mm, Height: page.Height * mm}
+	layers := map[string]*PenLayer{}
+	regions := map[*blgg.Clip]clipRegion{}
+	for _, shape := range shapes {
+		if !shape.Stroke || shape.Color.A <= 0 {
+			continue
+		}
+		path := shape.Path
+		if len(shape.Dash) > 0 {
+			path = path.Dash(shape.Dash, shape.DashOffset)
+		}
+		lines := path.Flatten(tolerance)
+		if len(shape.Clip) > 0 {
+			var clips []clipRegion
+			for _, clip := range shape.Clip {
+				region, ok := regions[clip]
+				if !ok {
+					region = newClipRegion(clip, tolerance)
+					regions[clip] = region
+				}
+				clips = append(clips, region)
+			}
+			var clipped [][]geom.Point
+			for _, line := range lines {
+				clipped = append(clipped, clipLine(line, clips)...)
+			}
+			lines = clipped
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		key, _ := svgColor(shape.Color)
+		layer, ok := layers[key]
+		if !ok {
+			layer = &PenLayer{Color: shape.Color}
+			layers[key] = layer
+			plot.Layers = append(plot.Layers, layer)
+		}
+		for _, line := range lines {
+			for i, p := range line {
+				line[i] = geom.Point{X: (p.X*scale + x) * mm, Y: (p.Y*scale + y) * mm}
+			}
+			layer.Lines = append(layer.Lines, line)
+		}
+	}
+	return plot, nil
+}
+
+// Write writes the plot in the format set in options.
+func (p *PenPlot) Write(w io.Writer, options PlotOptions) error {
+	if options.Format == GCode {
+		return p.WriteGCode(w, options)
+	}
+	return p.WriteHPGL(w)
+}
+
+// WriteHPGL writes the plot as HPGL, one pen per layer starting at pen 1.
+// Positions are in plotter units of 0.025mm from the bottom left of the paper.
+func (p *PenPlot) WriteHPGL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	unit := func(v float64) int {
+		return int(math.Round(v * 40))
+	}
+	fmt.Fprintf(bw, "IN;\n")
+	for i, layer := range p.Layers {
+		fmt.Fprintf(bw, "SP%d;\n", i+1)
+		for _, line := range layer.Lines {
+			fmt.Fprintf(bw, "PU%d,%d;PD", unit(line[0].X), unit(p.Height-line[0].Y))
+			for j, pt := range line[1:] {
+				if j > 0 {
+					bw.WriteByte(',')
+				}
+				fmt.Fprintf(bw, "%d,%d", unit(pt.X), unit(p.Height-pt.Y))
+			}
+			fmt.Fprintf(bw, ";\n")
+		}
+	}
+	fmt.Fprintf(bw, "PU;SP0;\n")
+	return bw.Flush()
+}
+
+// WriteGCode writes the plot as G-code using the pen commands and feed in options.
+// Positions are in millimeters from the bottom left of the paper. Before each layer after the first,
+// the program pauses with M0 so the pen can be changed.
+func (p *PenPlot) WriteGCode(w io.Writer, options PlotOptions) error {
+	penUp, penDown, feed := options.PenUp, options.PenDown, options.Feed
+	if penUp == "" {
+		penUp = GCodePenUp
+	}
+	if penDown == "" {
+		penDown = GCodePenDown
+	}
+	if feed <= 0 {
+		feed = 3000
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "; %smm x %smm, %d pens\n", vectorNum(p.Width), vectorNum(p.Height), len(p.Layers))
+	fmt.Fprintf(bw, "G21\nG90\n%s\n", penUp)
+	for i, layer := range p.Layers {
+		color, _ := svgColor(layer.Color)
+		if i > 0 {
+			fmt.Fprintf(bw, "G0 X0 Y0\nM0 ; change to pen %d %s\n", i+1, color)
+		} else {
+			fmt.Fprintf(bw, "; pen %d %s\n", i+1, color)
+		}
+		for _, line := range layer.Lines {
+			fmt.Fprintf(bw, "G0 X%s Y%s\n%s\n", vectorNum(line[0].X), vectorNum(p.Height-line[0].Y), penDown)
+			for j, pt := range line[1:] {
+				fmt.Fprintf(bw, "G1 X%s Y%s", vectorNum(pt.X), vectorNum(p.Height-pt.Y))
+				if j == 0 {
+					fmt.Fprintf(bw, " F%s", vectorNum(feed))
+				}
+				bw.WriteByte('\n')
+			}
+			fmt.Fprintf(bw, "%s\n", penUp)
+		}
+	}
+	fmt.Fprintf(bw, "G0 X0 Y0\nM2\n")
+	return bw.Flush()
+}
+
+// clipRegion is a clip path flattened into closed rings.
+type clipRegion struct {
+	rings   [][]geom.Point
+	evenOdd bool
+}
+
+// newClipRegion flattens a clip, closing any open subpaths as a fill would.
+func newClipRegion(clip *blgg.Clip, tolerance float64) clipRegion {
+	region := clipRegion{evenOdd: clip.FillRule == gg.FillRuleEvenOdd}
+	for _, ring := range clip.Path.Flatten(tolerance) {
+		if ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		region.rings = append(region.rings, ring)
+	}
+	return region
+}
+
+// contains reports whether a point is inside the region under its fill rule.
+func (r clipRegion) contains(p geom.Point) bool {
+	winding := 0
+	for _, ring := range r.rings {
+		for i := 0; i+1 < len(ring); i++ {
+			a, b := ring[i], ring[i+1]
+			side := (b.X-a.X)*(p.Y-a.Y) - (p.X-a.X)*(b.Y-a.Y)
+			if a.Y <= p.Y {
+				if b.Y > p.Y && side > 0 {
+					winding++
+				}
+			} else if b.Y <= p.Y && side < 0 {
+				winding--
+			}
+		}
+	}
+	if r.evenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// crossings appends the positions, from 0 to 1, at which the line from a to b crosses the region's edges.
+func (r clipRegion) crossings(a, b geom.Point, ts []float64) []float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	for _, ring := range r.rings {
+		for i := 0; i+1 < len(ring); i++ {
+			c, d := ring[i], ring[i+1]
+			ex, ey := d.X-c.X, d.Y-c.Y
+			denom := dx*ey - dy*ex
+			if denom == 0 {
+				continue
+			}
+			t := ((c.X-a.X)*ey - (c.Y-a.Y)*ex) / denom
+			u := ((c.X-a.X)*dy - (c.Y-a.Y)*dx) / denom
+			if t > 0 && t < 1 && u >= 0 && u <= 1 {
+				ts = append(ts, t)
+			}
+		}
+	}
+	return ts
+}
+
+// clipLine splits a polyline where it crosses the clips and returns the parts inside all of them.
+func clipLine(line []geom.Point, clips []clipRegion) [][]geom.Point {
+	var parts [][]geom.Point
+	var part []geom.Point
+	end := func() {
+		if len(part) > 1 {
+			parts = append(parts, part)
+		}
+		part = nil
+	}
+	for i := 0; i+1 < len(line); i++ {
+		a, b := line[i], line[i+1]
+		at := func(t float64) geom.Point {
+			return geom.Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+		}
+		ts := []float64{0, 1}
+		for _, clip := range clips {
+			ts = clip.crossings(a, b, ts)
+		}
+		sort.Float64s(ts)
+		for j := 0; j+1 < len(ts); j++ {
+			if ts[j+1]-ts[j] < 1e-9 {
+				continue
+			}
+			mid := at((ts[j] + ts[j+1]) / 2)
+			inside := true
+			for _, clip := range clips {
+				if !clip.contains(mid) {
+					inside = false
+					break
+				}
+			}
+			if !inside {
+				end()
+				continue
+			}
+			if part == nil {
+				part = append(part, at(ts[j]))
+			}
+			part = append(part, at(ts[j+1]))
+		}
+	}
+	end()
+	return parts
+}
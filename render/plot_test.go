@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestWritePlotHPGL(t *testing.T) {
+	r := newRenderer(100, 100, diagonal, Options{})
+	var b bytes.Buffer
+	_, err := WritePlot(&b, r.record(0), PlotOptions{Format: HPGL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// at 72 dpi one frame unit is a point, 25.4/72mm, and there are 40 plotter units to the millimeter.
+	// y is measured up from the bottom of the paper.
+	want := "IN;\nSP1;\nPU141,1270;PD1270,1270;\nPU;SP0;\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWritePlotGCode(t *testing.T) {
+	r := newRenderer(100, 100, diagonal, Options{})
+	var b bytes.Buffer
+	_, err := WritePlot(&b, r.record(0), PlotOptions{Format: GCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	want := []string{"G21", "G90", GCodePenUp, "; pen 1 #000000", "G0 X3.528 Y31.75", GCodePenDown, "G1 X31.75 Y31.75 F3000", GCodePenUp, "G0 X0 Y0", "M2"}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("got\n%s", b.String())
+	}
+	for i, line := range want {
+		if lines[i+1] != line {
+			t.Errorf("line %d is %q, want %q", i+2, lines[i+1], line)
+		}
+	}
+}
+
+func TestPlotStats(t *testing.T) {
+	// the line is drawn twice, so optimizing plots it once.
+	twice := func(context *blgg.Context, width, height, percent float64) {
+		diagonal(context, width, height, percent)
+		diagonal(context, width, height, percent)
+	}
+	path := filepath.Join(t.TempDir(), "out.hpgl")
+	stats, err := Plot(100, 100, path, twice, 0, PlotOptions{Format: HPGL, Optimize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LinesBefore != 2 || stats.LinesAfter != 1 {
+		t.Errorf("lines %d -> %d, want 2 -> 1", stats.LinesBefore, stats.LinesAfter)
+	}
+
+	var b bytes.Buffer
+	stats2, err := WritePlot(&b, newRenderer(100, 100, twice, Options{}).record(0), PlotOptions{Format: HPGL, Optimize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats2 != stats {
+		t.Errorf("WritePlot stats %v differ from Plot stats %v", stats2, stats)
+	}
+}
@@ -0,0 +1,334 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PreviewOptions holds the settings for the live preview server.
+type PreviewOptions struct {
+	Options
+	// NumFrames is the number of steps in the page's timeline. Defaults to 100.
+	NumFrames int
+	// FPS is the rate the timeline plays at in the page. Defaults to 30.
+	FPS float64
+	// Params are shown as sliders on the page. The frame function reads their values.
+	Params []*Param
+	// Watch lists files, such as data the sketch loads, that trigger a re-render when they change.
+	// The sketch's own code can not be swapped into a running program, see Preview.
+	Watch []string
+}
+
+// Param is a value that can be changed from the preview page while the sketch is running.
+// Frames are rendered while the server holds its lock, so a frame function can read Value safely.
+type Param struct {
+	Name  string
+	Value float64
+	Min   float64
+	Max   float64
+}
+
+// PreviewServer is an http handler that renders frames of a sketch on request
+// and pushes updates to open pages with server-sent events.
+type PreviewServer struct {
+	width, height float64
+	options       PreviewOptions
+	build         string
+
+	mu       sync.Mutex
+	renderer *renderer
+	version  int
+	clients  map[chan int]struct{}
+}
+
+// NewPreviewServer creates a PreviewServer for a sketch.
+func NewPreviewServer(width, height float64, frameFunc FrameFunc, options PreviewOptions) *PreviewServer {
+	if options.NumFrames < 1 {
+		options.NumFrames = 100
+	}
+	if options.FPS <= 0 {
+		options.FPS = 30
+	}
+	return &PreviewServer{
+		width:    width,
+		height:   height,
+		options:  options,
+		build:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		renderer: newRenderer(width, height, frameFunc, options.Options),
+		clients:  map[chan int]struct{}{},
+	}
+}
+
+// Preview serves a live preview of a sketch at addr until the server fails.
+// An empty addr uses localhost:8080.
+//
+// Changes to the sketch's code need the program restarted. Run it under a file watcher that
+// restarts it when a .go file changes, such as
+//
+//	ls *.go | entr -r go run . -target preview
+//
+// and open pages reconnect and reload with the new code once the server is back.
+func Preview(addr string, width, height float64, frameFunc FrameFunc, options PreviewOptions) error {
+	if addr == "" {
+		addr = "localhost:8080"
+	}
+	server := NewPreviewServer(width, height, frameFunc, options)
+	if len(options.Watch) > 0 {
+		go server.watch(options.Watch, 500*time.Millisecond)
+	}
+	fmt.Printf("Previewing at http://%s\n", addr)
+	fmt.Println("Restart the program to preview code changes, open pages reload when it is back.")
+	return http.ListenAndServe(addr, server)
+}
+
+// Reload makes every open page render its current frame again.
+func (s *PreviewServer) Reload() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	for client := range s.clients {
+		select {
+		case client <- s.version:
+		default:
+			// the client is still busy with an earlier update, which will fetch the latest frame anyway.
+		}
+	}
+}
+
+// ServeHTTP serves the page, frames, parameter changes and the event stream.
+func (s *PreviewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.servePage(w)
+	case "/frame.png":
+		s.serveFrame(w, r)
+	case "/param":
+		s.serveParam(w, r)
+	case "/events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveFrame renders the frame numbered in the query, timed by the loop mode as in a full render.
+func (s *PreviewServer) serveFrame(w http.ResponseWriter, r *http.Request) {
+	frame, err := strconv.Atoi(r.URL.Query().Get("frame"))
+	if err != nil || frame < 0 || frame >= s.options.NumFrames {
+		frame = 0
+	}
+	percent, duration := s.options.frameTime(frame, s.options.NumFrames)
+	var buf bytes.Buffer
+	s.mu.Lock()
+	img := s.renderer.frame(percent, duration)
+	err = png.Encode(&buf, img)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
+
+func (s *PreviewServer) serveParam(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.FormValue("name")
+	value, err := strconv.ParseFloat(r.FormValue("value"), 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	found := false
+	for _, param := range s.options.Params {
+		if param.Name == name {
+			param.Value = value
+			found = true
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		http.Error(w, "unknown param "+name, http.StatusNotFound)
+		return
+	}
+	s.Reload()
+}
+
+func (s *PreviewServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	client := make(chan int, 1)
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	version := s.version
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	// the build id tells a reconnecting page whether the program was restarted.
+	fmt.Fprintf(w, "event: hello\ndata: %s\n\n", s.build)
+	fmt.Fprintf(w, "event: update\ndata: %d\n\n", version)
+	flusher.Flush()
+	for {
+		select {
+		case version := <-client:
+			fmt.Fprintf(w, "event: update\ndata: %d\n\n", version)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// watch polls files for changes to their modification time and reloads when one changes.
+func (s *PreviewServer) watch(paths []string, interval time.Duration) {
+	modTimes := map[string]time.Time{}
+	for {
+		changed := false
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			if last, ok := modTimes[path]; ok && !info.ModTime().Equal(last) {
+				changed = true
+			}
+			modTimes[path] = info.ModTime()
+		}
+		if changed {
+			s.Reload()
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (s *PreviewServer) servePage(w http.ResponseWriter) {
+	s.mu.Lock()
+	data := struct {
+		Width, Height float64
+		NumFrames     int
+		LastFrame     int
+		FPS           float64
+		Params        []Param
+	}{s.width, s.height, s.options.NumFrames, s.options.NumFrames - 1, s.options.FPS, nil}
+	for _, param := range s.options.Params {
+		data.Params = append(data.Params, *param)
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := previewPage.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>blgg preview</title>
+<style>
+body { font-family: sans-serif; background: #444; color: #eee; margin: 20px; }
+img { display: block; background: #fff; margin-bottom: 10px; }
+input[type=range] { width: {{.Width}}px; }
+label { display: block; margin-top: 6px; }
+</style>
+</head>
+<body>
+<img id="frame" width="{{.Width}}" height="{{.Height}}">
+<div>
+<button id="play">play</button>
+<span id="label">frame 0</span>
+</div>
+<input id="timeline" type="range" min="0" max="{{.LastFrame}}" step="1" value="0">
+{{range .Params}}
+<label>{{.Name}} <span>{{.Value}}</span><br>
+<input class="param" type="range" data-name="{{.Name}}" min="{{.Min}}" max="{{.Max}}" step="any" value="{{.Value}}">
+</label>
+{{end}}
+<script>
+const numFrames = {{.NumFrames}};
+const fps = {{.FPS}};
+const img = document.getElementById("frame");
+const timeline = document.getElementById("timeline");
+const label = document.getElementById("label");
+let version = 0, build = "", loading = false, pending = false, playing = false;
+
+function show() {
+	if (loading) {
+		pending = true;
+		return;
+	}
+	loading = true;
+	label.textContent = "frame " + timeline.value;
+	img.src = "/frame.png?frame=" + timeline.value + "&v=" + version;
+}
+
+img.onload = img.onerror = () => {
+	loading = false;
+	if (pending) {
+		pending = false;
+		show();
+	}
+};
+
+timeline.oninput = show;
+
+document.getElementById("play").onclick = (e) => {
+	playing = !playing;
+	e.target.textContent = playing ? "pause" : "play";
+};
+
+setInterval(() => {
+	if (playing && !loading) {
+		timeline.value = (Number(timeline.value) + 1) % numFrames;
+		show();
+	}
+}, 1000 / fps);
+
+for (const slider of document.querySelectorAll(".param")) {
+	slider.oninput = () => {
+		slider.parentNode.querySelector("span").textContent = slider.value;
+		fetch("/param", {
+			method: "POST",
+			body: new URLSearchParams({name: slider.dataset.name, value: slider.value}),
+		});
+	};
+}
+
+const events = new EventSource("/events");
+events.addEventListener("hello", (e) => {
+	if (build && build !== e.data) {
+		location.reload();
+	}
+	build = e.data;
+});
+events.addEventListener("update", (e) => {
+	version = e.data;
+	show();
+});
+</script>
+</body>
+</html>
+`))
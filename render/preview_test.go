@@ -0,0 +1,27 @@
+package render
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestPreviewFrameTime(t *testing.T) {
+	var got float64
+	frameFunc := func(context *blgg.Context, width, height, percent float64) {
+		got = percent
+	}
+	server := NewPreviewServer(20, 10, frameFunc, PreviewOptions{Options: Options{Loop: LoopPingPong}, NumFrames: 4})
+	for frame, want := range []float64{0, 0.5, 1, 0.5} {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/frame.png?frame="+strconv.Itoa(frame), nil))
+		if w.Code != 200 {
+			t.Fatalf("frame %d: status %d", frame, w.Code)
+		}
+		if got != want {
+			t.Errorf("frame %d rendered at %v, want %v", frame, got, want)
+		}
+	}
+}
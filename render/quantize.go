@@ -0,0 +1,265 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+const (
+	// MedianCut builds a palette by repeatedly splitting the color space at the median.
+	MedianCut = iota
+	// Octree builds a palette by reducing an octree of colors.
+	Octree
+)
+
+// histogram counts the occurrences of each rgb color, keyed as 0xRRGGBB.
+type histogram map[uint32]uint64
+
+// add counts every pixel in an image.
+func (h histogram) add(img *image.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		i := img.PixOffset(b.Min.X, y)
+		for x := b.Min.X; x < b.Max.X; x++ {
+			h[rgbKey(img.Pix[i], img.Pix[i+1], img.Pix[i+2])]++
+			i += 4
+		}
+	}
+}
+
+// keys returns the colors in the histogram in ascending order, so that palettes do not depend on map order.
+func (h histogram) keys() []uint32 {
+	keys := make([]uint32, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// quantize builds a palette of at most numColors colors using the given quantizer.
+func (h histogram) quantize(quantizer, numColors int) color.Palette {
+	if quantizer == Octree {
+		return octreePalette(h, numColors)
+	}
+	return medianCutPalette(h, numColors)
+}
+
+func rgbKey(r, g, b uint8) uint32 {
+	return uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+}
+
+// //////////////////
+// MEDIAN CUT
+// //////////////////
+
+type weightedColor struct {
+	rgb   [3]uint8
+	count uint64
+}
+
+type colorBox []weightedColor
+
+// longestAxis returns the channel with the widest range in the box, and that range.
+func (b colorBox) longestAxis() (int, int) {
+	axis, size := 0, -1
+	for c := 0; c < 3; c++ {
+		lo, hi := 255, 0
+		for _, wc := range b {
+			v := int(wc.rgb[c])
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+		if hi-lo > size {
+			axis, size = c, hi-lo
+		}
+	}
+	return axis, size
+}
+
+// split sorts the box along its longest axis and divides it at the weighted median.
+// Colors level on that axis are ordered by their full key, so the split is always the same.
+func (b colorBox) split() (colorBox, colorBox) {
+	axis, _ := b.longestAxis()
+	sort.SliceStable(b, func(i, j int) bool {
+		if b[i].rgb[axis] != b[j].rgb[axis] {
+			return b[i].rgb[axis] < b[j].rgb[axis]
+		}
+		return rgbKey(b[i].rgb[0], b[i].rgb[1], b[i].rgb[2]) < rgbKey(b[j].rgb[0], b[j].rgb[1], b[j].rgb[2])
+	})
+	var total uint64
+	for _, wc := range b {
+		total += wc.count
+	}
+	var sum uint64
+	for i, wc := range b[:len(b)-1] {
+		sum += wc.count
+		if sum*2 >= total {
+			return b[:i+1], b[i+1:]
+		}
+	}
+	return b[:len(b)-1], b[len(b)-1:]
+}
+
+// average returns the count-weighted mean color of the box.
+func (b colorBox) average() color.Color {
+	var r, g, bl, n uint64
+	for _, wc := range b {
+		r += uint64(wc.rgb[0]) * wc.count
+		g += uint64(wc.rgb[1]) * wc.count
+		bl += uint64(wc.rgb[2]) * wc.count
+		n += wc.count
+	}
+	return color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), 255}
+}
+
+func medianCutPalette(h histogram, numColors int) color.Palette {
+	box := make(colorBox, 0, len(h))
+	for _, key := range h.keys() {
+		box = append(box, weightedColor{[3]uint8{uint8(key >> 16), uint8(key >> 8), uint8(key)}, h[key]})
+	}
+	boxes := []colorBox{box}
+	for len(boxes) < numColors {
+		best, bestSize := -1, 0
+		for i, b := range boxes {
+			if len(b) < 2 {
+				continue
+			}
+			if _, size := b.longestAxis(); size > bestSize {
+				best, bestSize = i, size
+			}
+		}
+		if best < 0 {
+			break
+		}
+		b0, b1 := boxes[best].split()
+		boxes[best] = b0
+		boxes = append(boxes, b1)
+	}
+	palette := make(color.Palette, 0, len(boxes))
+	for _, b := range boxes {
+		if len(b) > 0 {
+			palette = append(palette, b.average())
+		}
+	}
+	return palette
+}
+
+// //////////////////
+// OCTREE
+// //////////////////
+
+type octreeNode struct {
+	r, g, b, count uint64
+	// key is the first color added under the node, used to order nodes with equal counts.
+	key      uint32
+	leaf     bool
+	children [8]*octreeNode
+}
+
+type octree struct {
+	root      *octreeNode
+	reducible [8][]*octreeNode
+	leaves    int
+}
+
+func (t *octree) add(key uint32, count uint64) {
+	r, g, b := uint8(key>>16), uint8(key>>8), uint8(key)
+	node := t.root
+	for level := 0; ; level++ {
+		node.count += count
+		if node.leaf {
+			node.r += uint64(r) * count
+			node.g += uint64(g) * count
+			node.b += uint64(b) * count
+			return
+		}
+		shift := 7 - level
+		i := (r>>shift&1)<<2 | (g>>shift&1)<<1 | b>>shift&1
+		child := node.children[i]
+		if child == nil {
+			child = &octreeNode{key: key, leaf: level == 7}
+			if child.leaf {
+				t.leaves++
+			} else {
+				t.reducible[level+1] = append(t.reducible[level+1], child)
+			}
+			node.children[i] = child
+		}
+		node = child
+	}
+}
+
+// reduce merges the least used node at the deepest level into a single leaf.
+func (t *octree) reduce() bool {
+	level := 7
+	for level >= 0 && len(t.reducible[level]) == 0 {
+		level--
+	}
+	if level < 0 {
+		return false
+	}
+	list := t.reducible[level]
+	node := list[len(list)-1]
+	t.reducible[level] = list[:len(list)-1]
+
+	merged := 0
+	for i, child := range node.children {
+		if child == nil {
+			continue
+		}
+		node.r += child.r
+		node.g += child.g
+		node.b += child.b
+		node.children[i] = nil
+		merged++
+	}
+	node.leaf = true
+	t.leaves -= merged - 1
+	return true
+}
+
+func (t *octree) palette(node *octreeNode, palette color.Palette) color.Palette {
+	if node.leaf {
+		return append(palette, color.RGBA{
+			uint8(node.r / node.count),
+			uint8(node.g / node.count),
+			uint8(node.b / node.count),
+			255,
+		})
+	}
+	for _, child := range node.children {
+		if child != nil {
+			palette = t.palette(child, palette)
+		}
+	}
+	return palette
+}
+
+func octreePalette(h histogram, numColors int) color.Palette {
+	t := &octree{root: &octreeNode{}}
+	t.reducible[0] = []*octreeNode{t.root}
+	for _, key := range h.keys() {
+		t.add(key, h[key])
+	}
+	// Node counts are fixed once all colors are added, so each level is sorted
+	// once so that the least used node is always at the end.
+	for _, list := range t.reducible {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].count != list[j].count {
+				return list[i].count > list[j].count
+			}
+			return list[i].key < list[j].key
+		})
+	}
+	for t.leaves > numColors && t.reduce() {
+	}
+	return t.palette(t.root, nil)
+}
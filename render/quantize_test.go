@@ -0,0 +1,26 @@
+package render
+
+import (
+	"image"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestQuantizeStable(t *testing.T) {
+	// every color appears once, so the quantizers see many ties.
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	for _, quantizer := range []int{MedianCut, Octree} {
+		h := histogram{}
+		h.add(img)
+		want := h.quantize(quantizer, 16)
+		for i := 0; i < 10; i++ {
+			h := histogram{}
+			h.add(img)
+			if got := h.quantize(quantizer, 16); !reflect.DeepEqual(got, want) {
+				t.Fatalf("quantizer %d: palette changed between runs", quantizer)
+			}
+		}
+	}
+}
@@ -3,10 +3,9 @@ package render
 
 import (
 	"fmt"
-	"math"
-	"os"
+	"runtime"
+	"sync"
 
-	"github.com/bit101/bitlib/blcolor"
 	"github.com/bit101/blgg"
 )
 
@@ -21,53 +20,109 @@ const (
 	VideoTarget
 	// SpriteSheetTarget will render a sprite sheet.
 	SpriteSheetTarget
+	// APNGTarget will render an animated png.
+	APNGTarget
+	// Y4MTarget will render an uncompressed y4m video.
+	Y4MTarget
+	// AVITarget will render a Motion JPEG avi video.
+	AVITarget
+	// SVGTarget will render an svg image.
+	SVGTarget
+	// PDFTarget will render a vector pdf document.
+	PDFTarget
+	// PlotTarget will render HPGL or G-code for a pen plotter.
+	PlotTarget
 )
 
 // FrameFunc is the interface for a function that renders a single frame.
 type FrameFunc func(*blgg.Context, float64, float64, float64)
 
 // Image sets up the rendering of a single image.
-func Image(width, height float64, path string, frameFunc FrameFunc, percent float64) {
-	context := blgg.NewContext(int(width), int(height))
-	frameFunc(context, width, height, percent)
-	context.SavePNG(path)
+func Image(width, height float64, path string, frameFunc FrameFunc, percent float64, options Options) error {
+	r := newRenderer(width, height, frameFunc, options)
+	return savePNG(path, r.frame(percent, 0), frameText(options, width, height, percent))
 }
 
 // Frames sets up the renderin of a series of frames.
-func Frames(width, height float64, numFrames int, frames string, frameFunc FrameFunc) {
-	os.RemoveAll(frames)
-	os.MkdirAll(frames, 0755)
-	context := blgg.NewContext(int(width), int(height))
-	for frame := 0; frame < numFrames; frame++ {
-		percent := float64(frame) / float64(numFrames)
-		fmt.Printf("\r%f", percent)
-		frameFunc(context, width, height, percent)
-		context.SavePNG(fmt.Sprintf("%s/frame_%04d.png", frames, frame))
+func Frames(width, height float64, numFrames int, frames string, frameFunc FrameFunc, options FramesOptions) error {
+	todo, err := prepareFrames(width, height, numFrames, frames, options)
+	if err != nil {
+		return err
+	}
+	r := newRenderer(width, height, frameFunc, options.Options)
+	for _, frame := range todo {
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		img := r.frame(percent, duration)
+		err = savePNG(framePath(frames, frame), img, frameText(options.Options, width, height, percent))
+		if err != nil {
+			fmt.Println()
+			return err
+		}
 	}
 	fmt.Println("\nDone!")
+	return nil
 }
 
-// SpriteSheet sets up the rendering of a sprite sheet.
-func SpriteSheet(width, height float64, bg blcolor.Color, path string, numFrames int, frameFunc FrameFunc) {
-	x := 0.0
-	y := 0.0
-	nf := float64(numFrames)
-	size := math.Ceil(math.Sqrt(nf))
-	context := blgg.NewContext(int(width*size), int(height*size))
-	context.ClearColor(bg)
+// FramesParallel sets up the rendering of a series of frames across a number of workers.
+// Each worker gets its own context, so frameFunc must not rely on state shared between frames.
+// If workers is less than 1, one worker per CPU is used.
+// Rendering stops at the first frame that fails to save.
+func FramesParallel(width, height float64, numFrames, workers int, frames string, frameFunc FrameFunc, options FramesOptions) error {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	todo, err := prepareFrames(width, height, numFrames, frames, options)
+	if err != nil {
+		return err
+	}
 
-	for i := 0.0; i < nf; i++ {
-		context.Push()
-		context.Translate(x, y)
-		percent := i / float64(numFrames)
-		frameFunc(context, width, height, percent)
-		context.Pop()
+	jobs := make(chan int)
+	done := make(chan error)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := newRenderer(width, height, frameFunc, options.Options)
+			for frame := range jobs {
+				percent, duration := options.frameTime(frame, numFrames)
+				img := r.frame(percent, duration)
+				done <- savePNG(framePath(frames, frame), img, frameText(options.Options, width, height, percent))
+			}
+		}()
+	}
+	go func() {
+	feed:
+		for _, frame := range todo {
+			select {
+			case jobs <- frame:
+			case <-quit:
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
 
-		x += width
-		if x >= size*width {
-			x = 0.0
-			y += height
+	count := 0
+	for frameErr := range done {
+		if frameErr != nil {
+			if err == nil {
+				err = frameErr
+				close(quit)
+			}
+			continue
 		}
+		count++
+		fmt.Printf("\r%f", float64(count)/float64(len(todo)))
 	}
-	context.SavePNG(path)
+	if err != nil {
+		fmt.Println()
+		return err
+	}
+	fmt.Println("\nDone!")
+	return nil
 }
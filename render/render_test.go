@@ -0,0 +1,47 @@
+package render
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestFramesParallel(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	err := FramesParallel(20, 10, 30, 4, dir, moving, FramesOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for frame := 0; frame < 30; frame++ {
+		_, err := os.Stat(framePath(dir, frame))
+		if err != nil {
+			t.Errorf("frame %d: %v", frame, err)
+		}
+	}
+}
+
+func TestFramesParallelError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	// a folder where frame 5 should go makes saving it fail. End keeps the folder from being cleared.
+	err := os.MkdirAll(filepath.Join(framePath(dir, 5), "blocked"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	frameFunc := func(context *blgg.Context, width, height, percent float64) {
+		atomic.AddInt32(&calls, 1)
+		moving(context, width, height, percent)
+	}
+	err = FramesParallel(20, 10, 500, 4, dir, frameFunc, FramesOptions{End: 500})
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) {
+		t.Fatalf("got error %v, want a FileError", err)
+	}
+	if n := atomic.LoadInt32(&calls); n >= 500 {
+		t.Errorf("workers rendered all %d frames after the error", n)
+	}
+}
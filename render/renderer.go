@@ -0,0 +1,123 @@
+package render
+
+import (
+	"image"
+	"math/rand"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/blgg"
+)
+
+// renderer draws frames on its own context, applying the shared options.
+type renderer struct {
+	width, height float64
+	frameFunc     FrameFunc
+	options       Options
+	context       *blgg.Context
+	// background, if set, is cleared before every call to frameFunc.
+	background *blcolor.Color
+	// seed is the current frame's seed, if Options.Seed is set.
+	seed  int64
+	sum   []uint32
+	out   *image.RGBA
+	small *image.RGBA
+}
+
+func newRenderer(width, height float64, frameFunc FrameFunc, options Options) *renderer {
+	r := &renderer{
+		width:     width,
+		height:    height,
+		frameFunc: frameFunc,
+		options:   options,
+	}
+	if options.Supersample > 1 {
+		r.context = blgg.NewContextScaled(int(width), int(height), float64(options.Supersample))
+		r.small = image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	} else {
+		r.context = blgg.NewContext(int(width), int(height))
+	}
+	return r
+}
+
+// frame renders the frame at the given percent. duration is the length of one frame
+// as a percent, over which motion blur samples are spread.
+// The returned image is reused by the next call.
+func (r *renderer) frame(percent, duration float64) image.Image {
+	if r.options.Seed != 0 {
+		r.seed = FrameSeed(r.options.Seed, percent)
+	}
+	img := r.blurred(percent, duration)
+	if r.small == nil {
+		return img
+	}
+	downsample(r.small, img, r.options.Supersample, r.options.Filter)
+	return r.small
+}
+
+// record draws the frame at percent once and returns the recorded drawing calls, for vector output.
+// Motion blur and supersampling do not apply.
+func (r *renderer) record(percent float64) *blgg.DisplayList {
+	if r.options.Seed != 0 {
+		r.seed = FrameSeed(r.options.Seed, percent)
+	}
+	r.context.Record()
+	r.draw(percent)
+	return r.context.StopRecording()
+}
+
+// blurred renders a frame at full context size, averaging motion blur samples if enabled.
+func (r *renderer) blurred(percent, duration float64) *image.RGBA {
+	blur := r.options.MotionBlur
+	if !blur.enabled() {
+		r.draw(percent)
+		return r.context.Image().(*image.RGBA)
+	}
+
+	if r.out == nil {
+		r.out = image.NewRGBA(r.context.Image().Bounds())
+		r.sum = make([]uint32, len(r.out.Pix))
+	} else {
+		for i := range r.sum {
+			r.sum[i] = 0
+		}
+	}
+
+	// samples are centered on the frame's percent so that motion is blurred
+	// both ways and frame 0 of a loop still matches its neighbours.
+	open := blur.open() * duration
+	jitter := rand.Float64
+	if r.options.Seed != 0 {
+		// the jitter follows the frame's seed, so seeded renders come out the same every time.
+		jitter = rand.New(rand.NewSource(r.seed)).Float64
+	}
+	for s := 0; s < blur.Samples; s++ {
+		offset := 0.5
+		if blur.Jitter {
+			offset = jitter()
+		}
+		t := (float64(s)+offset)/float64(blur.Samples) - 0.5
+		r.draw(r.options.wrap(percent + t*open))
+		pix := r.context.Image().(*image.RGBA).Pix
+		for i, v := range pix {
+			r.sum[i] += uint32(v)
+		}
+	}
+
+	n := uint32(blur.Samples)
+	for i, v := range r.sum {
+		r.out.Pix[i] = uint8((v + n/2) / n)
+	}
+	return r.out
+}
+
+// draw calls frameFunc once. Every motion blur sample of a frame gets the same seed,
+// so random details stay still while the rest of the frame moves.
+func (r *renderer) draw(percent float64) {
+	if r.options.Seed != 0 {
+		r.context.Seed(r.seed)
+	}
+	if r.background != nil {
+		r.context.ClearColor(*r.background)
+	}
+	r.frameFunc(r.context, r.width, r.height, percent)
+}
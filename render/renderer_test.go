@@ -0,0 +1,19 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestJitterSeeded(t *testing.T) {
+	render := func() []byte {
+		options := Options{Seed: 7, MotionBlur: MotionBlur{Samples: 8, Shutter: 360, Jitter: true}}
+		r := newRenderer(100, 1, moving, options)
+		img := r.frame(0.5, 0.2).(*image.RGBA)
+		return append([]byte(nil), img.Pix...)
+	}
+	if !bytes.Equal(render(), render()) {
+		t.Error("seeded frames with jittered motion blur differ")
+	}
+}
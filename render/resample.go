@@ -0,0 +1,149 @@
+package render
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// BoxFilter averages each block of supersampled pixels.
+	BoxFilter = iota
+	// LanczosFilter resamples with a three lobed Lanczos kernel, which keeps edges sharper than a box.
+	LanczosFilter
+)
+
+// downsample reduces src into dst, which must be factor times smaller in each direction.
+func downsample(dst, src *image.RGBA, factor, filter int) {
+	if filter == LanczosFilter {
+		lanczosDownsample(dst, src, factor)
+		return
+	}
+	boxDownsample(dst, src, factor)
+}
+
+func boxDownsample(dst, src *image.RGBA, factor int) {
+	b := dst.Bounds()
+	n := uint32(factor * factor)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var sum [4]uint32
+			for sy := y * factor; sy < (y+1)*factor; sy++ {
+				i := src.PixOffset(x*factor, sy)
+				for sx := 0; sx < factor; sx++ {
+					sum[0] += uint32(src.Pix[i])
+					sum[1] += uint32(src.Pix[i+1])
+					sum[2] += uint32(src.Pix[i+2])
+					sum[3] += uint32(src.Pix[i+3])
+					i += 4
+				}
+			}
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[j+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+}
+
+// tap is one source pixel's contribution to an output pixel.
+type tap struct {
+	index  int
+	weight float64
+}
+
+// lanczosTaps computes the normalized source taps for every output pixel along one axis.
+func lanczosTaps(outSize, inSize, factor int) [][]tap {
+	const lobes = 3
+	f := float64(factor)
+	radius := lobes * f
+	taps := make([][]tap, outSize)
+	for i := range taps {
+		center := (float64(i)+0.5)*f - 0.5
+		total := 0.0
+		for s := int(math.Ceil(center - radius)); s <= int(math.Floor(center+radius)); s++ {
+			w := lanczos((float64(s)-center)/f, lobes)
+			if w == 0 {
+				continue
+			}
+			index := s
+			if index < 0 {
+				index = 0
+			} else if index >= inSize {
+				index = inSize - 1
+			}
+			taps[i] = append(taps[i], tap{index, w})
+			total += w
+		}
+		for j := range taps[i] {
+			taps[i][j].weight /= total
+		}
+	}
+	return taps
+}
+
+func lanczos(x, lobes float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if x <= -lobes || x >= lobes {
+		return 0
+	}
+	px := math.Pi * x
+	return lobes * math.Sin(px) * math.Sin(px/lobes) / (px * px)
+}
+
+func lanczosDownsample(dst, src *image.RGBA, factor int) {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := dst.Bounds().Dx(), dst.Bounds().Dy()
+	xTaps := lanczosTaps(dw, sw, factor)
+	yTaps := lanczosTaps(dh, sh, factor)
+
+	// horizontal pass into a float buffer of dw x sh, then vertical into dst.
+	tmp := make([]float64, dw*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[src.PixOffset(0, y):]
+		for x, taps := range xTaps {
+			k := (y*dw + x) * 4
+			for _, t := range taps {
+				i := t.index * 4
+				tmp[k] += float64(row[i]) * t.weight
+				tmp[k+1] += float64(row[i+1]) * t.weight
+				tmp[k+2] += float64(row[i+2]) * t.weight
+				tmp[k+3] += float64(row[i+3]) * t.weight
+			}
+		}
+	}
+	for y, taps := range yTaps {
+		for x := 0; x < dw; x++ {
+			var sum [4]float64
+			for _, t := range taps {
+				k := (t.index*dw + x) * 4
+				sum[0] += tmp[k] * t.weight
+				sum[1] += tmp[k+1] * t.weight
+				sum[2] += tmp[k+2] * t.weight
+				sum[3] += tmp[k+3] * t.weight
+			}
+			// lanczos rings, so keep the result a valid premultiplied color.
+			a := clampByte(sum[3])
+			j := dst.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				v := clampByte(sum[c])
+				if v > a {
+					v = a
+				}
+				dst.Pix[j+c] = v
+			}
+			dst.Pix[j+3] = a
+		}
+	}
+}
+
+func clampByte(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
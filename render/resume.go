@@ -0,0 +1,138 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hashFile is written into a frames folder to record the parameters its frames were rendered with.
+const hashFile = ".render-hash"
+
+// FramesOptions holds the settings for rendering a folder of frames.
+type FramesOptions struct {
+	Options
+	// Resume keeps frames already in the folder and renders only the missing ones.
+	Resume bool
+	// Start and End limit rendering to frames Start up to, but not including, End.
+	// An End of 0 renders to the last frame. Frames outside the range are kept.
+	Start, End int
+	// Params describes whatever else the frames depend on, such as the sketch's settings.
+	// If set, a hash of it and the render settings is recorded in the folder, and existing
+	// frames rendered with a different hash are treated as stale and rendered again.
+	// If there are stale frames outside Start and End, rendering stops with an error instead.
+	Params interface{}
+}
+
+// keepsFrames reports whether existing frames in the folder are kept.
+func (o FramesOptions) keepsFrames() bool {
+	return o.Resume || o.Start > 0 || o.End > 0
+}
+
+// frameRange returns the first frame to render and the frame after the last.
+func (o FramesOptions) frameRange(numFrames int) (int, int) {
+	end := o.End
+	if end <= 0 || end > numFrames {
+		end = numFrames
+	}
+	return o.Start, end
+}
+
+// framePath returns the path of a single frame in a frames folder.
+func framePath(frames string, frame int) string {
+	return fmt.Sprintf("%s/frame_%04d.png", frames, frame)
+}
+
+// prepareFrames sets up the frames folder and returns the frames that need rendering.
+func prepareFrames(width, height float64, numFrames int, frames string, options FramesOptions) ([]int, error) {
+	if !options.keepsFrames() {
+		err := resetDir(frames)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := os.MkdirAll(frames, 0755)
+		if err != nil {
+			return nil, &FileError{"mkdir", frames, err}
+		}
+	}
+
+	if options.Params != nil {
+		err := checkFramesHash(width, height, numFrames, frames, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start, end := options.frameRange(numFrames)
+	var todo []int
+	for frame := start; frame < end; frame++ {
+		if options.Resume {
+			_, err := os.Stat(framePath(frames, frame))
+			if err == nil {
+				continue
+			}
+		}
+		todo = append(todo, frame)
+	}
+	return todo, nil
+}
+
+// checkFramesHash compares the hash recorded in the folder with the current one,
+// removing existing frames if they do not match, and records the current hash.
+// Stale frames outside the range being rendered are never removed, so they are an error.
+func checkFramesHash(width, height float64, numFrames int, frames string, options FramesOptions) error {
+	hash, err := framesHash(width, height, numFrames, options)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(frames, hashFile)
+	old, err := os.ReadFile(path)
+	if err == nil && strings.TrimSpace(string(old)) == hash {
+		return nil
+	}
+
+	stale, err := filepath.Glob(filepath.Join(frames, "frame_*.png"))
+	if err != nil {
+		return &FileError{"glob", frames, err}
+	}
+	start, end := options.frameRange(numFrames)
+	for _, frame := range stale {
+		var n int
+		_, err = fmt.Sscanf(filepath.Base(frame), "frame_%d.png", &n)
+		if err == nil && (n < start || n >= end) {
+			return &FileError{"resume", frames, errors.New("frames outside the range were rendered with other settings, clear the folder or render every frame")}
+		}
+	}
+	for _, frame := range stale {
+		err = os.Remove(frame)
+		if err != nil {
+			return &FileError{"remove", frame, err}
+		}
+	}
+	err = os.WriteFile(path, []byte(hash+"\n"), 0644)
+	if err != nil {
+		return &FileError{"write", path, err}
+	}
+	return nil
+}
+
+// framesHash hashes everything that affects the content of the frames.
+func framesHash(width, height float64, numFrames int, options FramesOptions) (string, error) {
+	data, err := json.Marshal(struct {
+		Width, Height float64
+		NumFrames     int
+		Options       Options
+		Params        interface{}
+	}{width, height, numFrames, options.Options, options.Params})
+	if err != nil {
+		return "", &EncodeError{"hash", err}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
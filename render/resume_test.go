@@ -0,0 +1,34 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFramesStaleOutsideRange(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	err := Frames(20, 10, 4, dir, moving, FramesOptions{Params: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Frames(20, 10, 4, dir, moving, FramesOptions{Params: "b", Start: 1, End: 3})
+	if err == nil {
+		t.Fatal("rendering a range with changed params over stale frames did not fail")
+	}
+	for frame := 0; frame < 4; frame++ {
+		_, err := os.Stat(framePath(dir, frame))
+		if err != nil {
+			t.Errorf("frame %d was removed", frame)
+		}
+	}
+
+	err = Frames(20, 10, 4, dir, moving, FramesOptions{Params: "b", Resume: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Frames(20, 10, 4, dir, moving, FramesOptions{Params: "b", Start: 1, End: 3})
+	if err != nil {
+		t.Errorf("rendering a range with matching params failed: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/blgg"
+)
+
+const (
+	// AtlasJSON writes a plain json description of the sheet and its frames.
+	AtlasJSON = iota
+	// AtlasTexturePacker writes a TexturePacker json array atlas, which Phaser and most engines can load.
+	AtlasTexturePacker
+)
+
+// SpriteSheetOptions holds the layout settings for a sprite sheet.
+type SpriteSheetOptions struct {
+	Options
+	// Columns is the number of frames per row. Defaults to a square grid.
+	Columns int
+	// Padding is the number of empty pixels around and between cells.
+	Padding int
+	// Extrude repeats each frame's edge pixels outwards by this many pixels,
+	// so texture filtering does not sample the background or a neighbour.
+	Extrude int
+	// PowerOfTwo rounds the sheet's width and height up to powers of two.
+	PowerOfTwo bool
+	// Atlas is the path of the atlas file to write alongside the sheet. Empty writes none.
+	Atlas string
+	// AtlasFormat is AtlasJSON or AtlasTexturePacker.
+	AtlasFormat int
+}
+
+// SpriteFrame describes where a single frame sits in a sprite sheet.
+type SpriteFrame struct {
+	Index   int     `json:"index"`
+	Percent float64 `json:"percent"`
+	X       int     `json:"x"`
+	Y       int     `json:"y"`
+	W       int     `json:"w"`
+	H       int     `json:"h"`
+}
+
+// SpriteAtlas describes a sprite sheet and the location of every frame in it.
+type SpriteAtlas struct {
+	Image  string        `json:"image"`
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Frames []SpriteFrame `json:"frames"`
+	// Seed is the Options.Seed the sheet was rendered with, if any.
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// SpriteSheet sets up the rendering of a sprite sheet.
+// Each frame is rendered on its own context, cleared to bg, and copied into its cell,
+// so a frame can never draw into its neighbour's cell.
+func SpriteSheet(width, height float64, bg blcolor.Color, path string, numFrames int, frameFunc FrameFunc, options SpriteSheetOptions) error {
+	w, h := int(width), int(height)
+	atlas := layoutSprites(w, h, numFrames, options)
+	atlas.Image = filepath.Base(path)
+
+	context := blgg.NewContext(atlas.Width, atlas.Height)
+	context.ClearColor(bg)
+	sheet := context.Image().(*image.RGBA)
+	r := newRenderer(width, height, frameFunc, options.Options)
+	r.background = &bg
+
+	_, duration := options.frameTime(0, numFrames)
+	for _, frame := range atlas.Frames {
+		rect := image.Rect(frame.X, frame.Y, frame.X+w, frame.Y+h)
+		draw.Draw(sheet, rect, r.frame(frame.Percent, duration), image.Point{}, draw.Src)
+		extrude(sheet, rect, options.Extrude)
+	}
+
+	err := savePNG(path, sheet, pngText(options.Options, float64(atlas.Width), float64(atlas.Height)))
+	if err != nil {
+		return err
+	}
+	if options.Atlas == "" {
+		return nil
+	}
+	return writeAtlas(options.Atlas, atlas, options.AtlasFormat)
+}
+
+// layoutSprites works out the sheet size and the position of each frame.
+func layoutSprites(w, h, numFrames int, options SpriteSheetOptions) *SpriteAtlas {
+	columns := options.Columns
+	if columns < 1 {
+		columns = int(math.Ceil(math.Sqrt(float64(numFrames))))
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	rows := (numFrames + columns - 1) / columns
+	e := options.Extrude
+	cellW := w + 2*e + options.Padding
+	cellH := h + 2*e + options.Padding
+
+	atlas := &SpriteAtlas{
+		Width:  options.Padding + columns*cellW,
+		Height: options.Padding + rows*cellH,
+		Seed:   options.Seed,
+	}
+	if options.PowerOfTwo {
+		atlas.Width = nextPowerOfTwo(atlas.Width)
+		atlas.Height = nextPowerOfTwo(atlas.Height)
+	}
+	for i := 0; i < numFrames; i++ {
+		percent, _ := options.frameTime(i, numFrames)
+		atlas.Frames = append(atlas.Frames, SpriteFrame{
+			Index:   i,
+			Percent: percent,
+			X:       options.Padding + (i%columns)*cellW + e,
+			Y:       options.Padding + (i/columns)*cellH + e,
+			W:       w,
+			H:       h,
+		})
+	}
+	return atlas
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+// extrude copies the edge pixels of rect outwards by the given number of pixels.
+func extrude(img *image.RGBA, rect image.Rectangle, amount int) {
+	if amount < 1 {
+		return
+	}
+	for i := 1; i <= amount; i++ {
+		top := image.Rect(rect.Min.X, rect.Min.Y-i, rect.Max.X, rect.Min.Y-i+1)
+		draw.Draw(img, top, img, rect.Min, draw.Src)
+		bottom := image.Rect(rect.Min.X, rect.Max.Y+i-1, rect.Max.X, rect.Max.Y+i)
+		draw.Draw(img, bottom, img, image.Pt(rect.Min.X, rect.Max.Y-1), draw.Src)
+	}
+	// the columns include the extruded rows, which fills the corners.
+	outer := rect.Inset(-amount)
+	for i := 1; i <= amount; i++ {
+		left := image.Rect(rect.Min.X-i, outer.Min.Y, rect.Min.X-i+1, outer.Max.Y)
+		draw.Draw(img, left, img, image.Pt(rect.Min.X, outer.Min.Y), draw.Src)
+		right := image.Rect(rect.Max.X+i-1, outer.Min.Y, rect.Max.X+i, outer.Max.Y)
+		draw.Draw(img, right, img, image.Pt(rect.Max.X-1, outer.Min.Y), draw.Src)
+	}
+}
+
+// texturePackerFrame is a single entry in a TexturePacker json array atlas.
+type texturePackerFrame struct {
+	Filename         string          `json:"filename"`
+	Frame            texturePackerXY `json:"frame"`
+	Rotated          bool            `json:"rotated"`
+	Trimmed          bool            `json:"trimmed"`
+	SpriteSourceSize texturePackerXY `json:"spriteSourceSize"`
+	SourceSize       texturePackerWH `json:"sourceSize"`
+}
+
+type texturePackerXY struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerWH struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type texturePackerMeta struct {
+	App     string          `json:"app"`
+	Version string          `json:"version"`
+	Image   string          `json:"image"`
+	Format  string          `json:"format"`
+	Size    texturePackerWH `json:"size"`
+	Scale   string          `json:"scale"`
+}
+
+type texturePackerAtlas struct {
+	Frames []texturePackerFrame `json:"frames"`
+	Meta   texturePackerMeta    `json:"meta"`
+}
+
+// texturePacker converts the atlas to the TexturePacker json array format.
+func (a *SpriteAtlas) texturePacker() texturePackerAtlas {
+	tp := texturePackerAtlas{
+		Meta: texturePackerMeta{
+			App:     "blgg",
+			Version: "1.0",
+			Image:   a.Image,
+			Format:  "RGBA8888",
+			Size:    texturePackerWH{a.Width, a.Height},
+			Scale:   "1",
+		},
+	}
+	for _, f := range a.Frames {
+		tp.Frames = append(tp.Frames, texturePackerFrame{
+			Filename:         fmt.Sprintf("frame_%04d", f.Index),
+			Frame:            texturePackerXY{f.X, f.Y, f.W, f.H},
+			SpriteSourceSize: texturePackerXY{0, 0, f.W, f.H},
+			SourceSize:       texturePackerWH{f.W, f.H},
+		})
+	}
+	return tp
+}
+
+func writeAtlas(path string, atlas *SpriteAtlas, format int) error {
+	var data interface{} = atlas
+	if format == AtlasTexturePacker {
+		data = atlas.texturePacker()
+	}
+	bytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return &EncodeError{"atlas", err}
+	}
+	err = os.WriteFile(path, bytes, 0644)
+	if err != nil {
+		return &FileError{"write", path, err}
+	}
+	return nil
+}
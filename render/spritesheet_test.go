@@ -0,0 +1,122 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bit101/bitlib/blcolor"
+)
+
+func TestLayoutSprites(t *testing.T) {
+	options := SpriteSheetOptions{Columns: 3, Padding: 2, Extrude: 1}
+	atlas := layoutSprites(10, 8, 5, options)
+	// each cell is the frame, an extruded pixel either side and the padding.
+	if atlas.Width != 2+3*14 || atlas.Height != 2+2*12 {
+		t.Errorf("sheet is %dx%d, want 44x26", atlas.Width, atlas.Height)
+	}
+	want := [][2]int{{3, 3}, {17, 3}, {31, 3}, {3, 15}, {17, 15}}
+	for i, frame := range atlas.Frames {
+		if frame.X != want[i][0] || frame.Y != want[i][1] || frame.W != 10 || frame.H != 8 {
+			t.Errorf("frame %d at %d,%d %dx%d, want %d,%d 10x8", i, frame.X, frame.Y, frame.W, frame.H, want[i][0], want[i][1])
+		}
+		if percent, _ := options.frameTime(i, 5); frame.Index != i || frame.Percent != percent {
+			t.Errorf("frame %d has index %d and percent %v", i, frame.Index, frame.Percent)
+		}
+	}
+
+	options.PowerOfTwo = true
+	atlas = layoutSprites(10, 8, 5, options)
+	if atlas.Width != 64 || atlas.Height != 32 {
+		t.Errorf("power of two sheet is %dx%d, want 64x32", atlas.Width, atlas.Height)
+	}
+}
+
+func TestExtrude(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	rect := image.Rect(3, 3, 6, 6)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	extrude(img, rect, 2)
+	tests := []struct {
+		x, y   int
+		source image.Point
+	}{
+		{4, 1, image.Pt(4, 3)},
+		{4, 7, image.Pt(4, 5)},
+		{1, 4, image.Pt(3, 4)},
+		{7, 4, image.Pt(5, 4)},
+		{1, 1, image.Pt(3, 3)},
+		{7, 7, image.Pt(5, 5)},
+		{2, 7, image.Pt(3, 5)},
+	}
+	for _, test := range tests {
+		if got, want := img.At(test.x, test.y), img.At(test.source.X, test.source.Y); got != want {
+			t.Errorf("pixel %d,%d is %v, want %v from %v", test.x, test.y, got, want, test.source)
+		}
+	}
+	if got := img.At(0, 0); got != (color.RGBA{}) {
+		t.Errorf("pixel 0,0 outside the extrusion is %v", got)
+	}
+}
+
+func TestSpriteSheetAtlas(t *testing.T) {
+	dir := t.TempDir()
+	options := SpriteSheetOptions{Columns: 2, Padding: 1, Atlas: filepath.Join(dir, "atlas.json")}
+	err := SpriteSheet(20, 10, blcolor.White(), filepath.Join(dir, "sheet.png"), 3, moving, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(options.Atlas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var atlas SpriteAtlas
+	err = json.Unmarshal(data, &atlas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := layoutSprites(20, 10, 3, options)
+	want.Image = "sheet.png"
+	if !reflect.DeepEqual(&atlas, want) {
+		t.Errorf("atlas %+v, want %+v", atlas, want)
+	}
+
+	options.AtlasFormat = AtlasTexturePacker
+	err = SpriteSheet(20, 10, blcolor.White(), filepath.Join(dir, "sheet.png"), 3, moving, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(options.Atlas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tp texturePackerAtlas
+	err = json.Unmarshal(data, &tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp.Meta.Image != "sheet.png" || tp.Meta.Size != (texturePackerWH{want.Width, want.Height}) {
+		t.Errorf("meta %+v does not match the sheet", tp.Meta)
+	}
+	if len(tp.Frames) != 3 {
+		t.Fatalf("%d frames, want 3", len(tp.Frames))
+	}
+	for i, frame := range tp.Frames {
+		f := want.Frames[i]
+		if frame.Frame != (texturePackerXY{f.X, f.Y, f.W, f.H}) {
+			t.Errorf("frame %d rect %+v, want %+v", i, frame.Frame, f)
+		}
+		if frame.Filename != fmt.Sprintf("frame_%04d", i) {
+			t.Errorf("frame %d is named %q", i, frame.Filename)
+		}
+	}
+}
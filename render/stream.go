@@ -0,0 +1,142 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// StreamRGBA writes each frame as raw, non premultiplied rgba bytes.
+	StreamRGBA = iota
+	// StreamY4M writes the frames as a YUV4MPEG2 stream.
+	StreamY4M
+)
+
+// FfmpegCommand encodes a raw rgba stream into an h264 video with ffmpeg.
+var FfmpegCommand = []string{
+	"ffmpeg", "-y", "-f", "rawvideo", "-pix_fmt", "rgba", "-s", "{width}x{height}",
+	"-framerate", "{fps}", "-i", "-", "-c:v", "libx264", "-pix_fmt", "yuv420p", "{output}",
+}
+
+// GifskiCommand encodes a y4m stream into a high quality gif with gifski. Use it with StreamY4M.
+var GifskiCommand = []string{"gifski", "--fps", "{fps}", "-o", "{output}", "-"}
+
+// StreamOptions holds the settings used when streaming frames to an external encoder.
+type StreamOptions struct {
+	Options
+	// FPS is the frame rate passed to the encoder. Defaults to 30.
+	FPS float64
+	// Command is the encoder's command line. Arguments may contain the placeholders
+	// {width}, {height}, {fps} and {output}. Defaults to FfmpegCommand.
+	Command []string
+	// Format is the format of the stream, StreamRGBA or StreamY4M.
+	Format int
+}
+
+// Stream renders a series of frames and pipes them into the stdin of a single encoder process,
+// so no frames are written to disk. Cancelling ctx stops rendering and kills the encoder.
+func Stream(ctx context.Context, width, height float64, numFrames int, output string, frameFunc FrameFunc, options StreamOptions) error {
+	fps := options.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+	command := options.Command
+	if len(command) == 0 {
+		command = FfmpegCommand
+	}
+	args := expandCommand(command, int(width), int(height), fps, output)
+
+	tool := args[0]
+	if _, err := exec.LookPath(tool); err != nil {
+		return &MissingToolError{tool}
+	}
+	cmd := exec.CommandContext(ctx, tool, args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return &ToolError{tool, "", err}
+	}
+	err = cmd.Start()
+	if err != nil {
+		return &ToolError{tool, "", err}
+	}
+
+	writeErr := streamFrames(ctx, stdin, width, height, numFrames, fps, frameFunc, options)
+	stdin.Close()
+	waitErr := cmd.Wait()
+	fmt.Println()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// a failed write usually means the encoder exited, so its own error explains more.
+	if waitErr != nil {
+		return &ToolError{tool, strings.TrimSpace(stderr.String()), waitErr}
+	}
+	if writeErr != nil {
+		return &ToolError{tool, strings.TrimSpace(stderr.String()), writeErr}
+	}
+	fmt.Println("Done!")
+	return nil
+}
+
+// expandCommand fills in the placeholders in an encoder command line.
+func expandCommand(command []string, width, height int, fps float64, output string) []string {
+	replacer := strings.NewReplacer(
+		"{width}", strconv.Itoa(width),
+		"{height}", strconv.Itoa(height),
+		"{fps}", strconv.FormatFloat(fps, 'f', -1, 64),
+		"{output}", output,
+	)
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
+
+// streamFrames renders each frame and writes it to w in the chosen format.
+func streamFrames(ctx context.Context, w io.Writer, width, height float64, numFrames int, fps float64, frameFunc FrameFunc, options StreamOptions) error {
+	r := newRenderer(width, height, frameFunc, options.Options)
+	if options.Format == StreamY4M {
+		y4m := NewY4MWriter(w, int(width), int(height), fps)
+		for frame := 0; frame < numFrames; frame++ {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			percent, duration := options.frameTime(frame, numFrames)
+			fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+			err := y4m.WriteFrame(r.frame(percent, duration))
+			if err != nil {
+				return err
+			}
+		}
+		return y4m.Flush()
+	}
+
+	bw := bufio.NewWriter(w)
+	nrgba := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	for frame := 0; frame < numFrames; frame++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		img := r.frame(percent, duration)
+		draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		_, err := bw.Write(nrgba.Pix)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
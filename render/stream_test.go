@@ -0,0 +1,40 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// consumeCommand runs the stand-in encoder in testdata/consume.
+var consumeCommand = []string{"go", "run", "./testdata/consume", "{width}", "{height}", "{output}"}
+
+func TestStream(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "frames.txt")
+	err := Stream(context.Background(), 20, 10, 5, output, moving, StreamOptions{Command: consumeCommand})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "5" {
+		t.Errorf("encoder read %s frames, want 5", got)
+	}
+}
+
+func TestStreamEncoderError(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "missing", "frames.txt")
+	err := Stream(context.Background(), 20, 10, 5, output, moving, StreamOptions{Command: consumeCommand})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("got error %v, want a ToolError", err)
+	}
+	if toolErr.Stderr == "" {
+		t.Error("ToolError does not include the encoder's stderr")
+	}
+}
@@ -0,0 +1,182 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/blgg"
+	"github.com/fogleman/gg"
+)
+
+// SVG renders a single frame as an svg file, recording the drawing calls instead of keeping pixels.
+// Paths, fills, strokes, transforms, line settings, colors and clipping are kept as vectors.
+// Text and images are not recorded. Motion blur and supersampling do not apply.
+func SVG(width, height float64, path string, frameFunc FrameFunc, percent float64, options Options) error {
+	r := newRenderer(width, height, frameFunc, Options{Seed: options.Seed})
+	list := r.record(percent)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	err = WriteSVG(file, list)
+	if err != nil {
+		file.Close()
+		return &EncodeError{"svg", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	return nil
+}
+
+// WriteSVG writes a display list as an svg document the size of the list.
+func WriteSVG(w io.Writer, list *blgg.DisplayList) error {
+	shapes, err := list.Shapes()
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		vectorNum(list.Width), vectorNum(list.Height), vectorNum(list.Width), vectorNum(list.Height))
+
+	// clips are nested groups. open holds the clips of the groups currently open.
+	var open []*blgg.Clip
+	ids := map[*blgg.Clip]int{}
+	for _, shape := range shapes {
+		if shape.Color.A <= 0 {
+			continue
+		}
+		same := 0
+		for same < len(open) && same < len(shape.Clip) && open[same] == shape.Clip[same] {
+			same++
+		}
+		for len(open) > same {
+			fmt.Fprintf(bw, "</g>\n")
+			open = open[:len(open)-1]
+		}
+		for _, clip := range shape.Clip[same:] {
+			id, ok := ids[clip]
+			if !ok {
+				id = len(ids) + 1
+				ids[clip] = id
+				fmt.Fprintf(bw, "<clipPath id=\"clip%d\"><path d=\"%s\"%s/></clipPath>\n",
+					id, svgPath(clip.Path), svgRule("clip-rule", clip.FillRule))
+			}
+			fmt.Fprintf(bw, "<g clip-path=\"url(#clip%d)\">\n", id)
+			open = append(open, clip)
+		}
+		fmt.Fprintf(bw, "<path d=\"%s\"%s/>\n", svgPath(shape.Path), svgPaint(shape))
+	}
+	for range open {
+		fmt.Fprintf(bw, "</g>\n")
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// svgPath converts a path into svg path data.
+func svgPath(path blgg.Path) string {
+	var b strings.Builder
+	for _, s := range path.Segments {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		switch s.Kind {
+		case blgg.SegmentMove:
+			b.WriteString("M")
+		case blgg.SegmentLine:
+			b.WriteString("L")
+		case blgg.SegmentQuad:
+			b.WriteString("Q")
+		case blgg.SegmentCubic:
+			b.WriteString("C")
+		case blgg.SegmentClose:
+			b.WriteString("Z")
+		}
+		for i, p := range s.Points {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(vectorNum(p.X))
+			b.WriteByte(' ')
+			b.WriteString(vectorNum(p.Y))
+		}
+	}
+	return b.String()
+}
+
+// svgPaint returns the fill and stroke attributes for a shape.
+func svgPaint(shape blgg.Shape) string {
+	color, opacity := svgColor(shape.Color)
+	if !shape.Stroke {
+		attrs := " fill=\"" + color + "\""
+		if opacity < 1 {
+			attrs += " fill-opacity=\"" + vectorNum(opacity) + "\""
+		}
+		return attrs + svgRule("fill-rule", shape.FillRule)
+	}
+
+	attrs := " fill=\"none\" stroke=\"" + color + "\" stroke-width=\"" + vectorNum(shape.LineWidth) + "\""
+	if opacity < 1 {
+		attrs += " stroke-opacity=\"" + vectorNum(opacity) + "\""
+	}
+	switch shape.LineCap {
+	case gg.LineCapButt:
+		attrs += " stroke-linecap=\"butt\""
+	case gg.LineCapSquare:
+		attrs += " stroke-linecap=\"square\""
+	default:
+		attrs += " stroke-linecap=\"round\""
+	}
+	if shape.LineJoin == gg.LineJoinBevel {
+		attrs += " stroke-linejoin=\"bevel\""
+	} else {
+		attrs += " stroke-linejoin=\"round\""
+	}
+	if len(shape.Dash) > 0 {
+		dashes := make([]string, len(shape.Dash))
+		for i, d := range shape.Dash {
+			dashes[i] = vectorNum(d)
+		}
+		attrs += " stroke-dasharray=\"" + strings.Join(dashes, " ") + "\""
+		if shape.DashOffset != 0 {
+			attrs += " stroke-dashoffset=\"" + vectorNum(shape.DashOffset) + "\""
+		}
+	}
+	return attrs
+}
+
+// svgRule returns the attribute for the even-odd fill rule, or nothing for svg's default nonzero rule.
+func svgRule(name string, rule gg.FillRule) string {
+	if rule == gg.FillRuleEvenOdd {
+		return " " + name + "=\"evenodd\""
+	}
+	return ""
+}
+
+// svgColor returns a color as a hex string and its opacity, clamped to 0 to 1.
+func svgColor(c blcolor.Color) (string, float64) {
+	channel := func(v float64) int {
+		return int(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(c.R), channel(c.G), channel(c.B)), math.Max(0, math.Min(1, c.A))
+}
+
+// vectorNum formats a number for svg or pdf, with at most three decimal places and no exponent.
+func vectorNum(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		// avoids "-0".
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
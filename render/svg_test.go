@@ -0,0 +1,46 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/bit101/blgg"
+)
+
+func TestWriteSVG(t *testing.T) {
+	op := func(name string, args ...float64) blgg.Op {
+		return blgg.Op{Name: name, Args: args}
+	}
+	list := &blgg.DisplayList{Width: 20, Height: 10, Ops: []blgg.Op{
+		op("SetRGBA", 1, 1, 1, 1),
+		op("Clear"),
+		op("DrawRectangle", 2, 2, 10, 6),
+		op("Clip"),
+		op("SetRGBA", 1, 0, 0, 0.5),
+		op("SetFillRule", 1),
+		op("DrawRectangle", 0, 0, 8, 8),
+		op("Fill"),
+		op("ResetClip"),
+		op("SetRGBA", 0, 0, 1, 1),
+		op("SetLineWidth", 2),
+		op("SetDash", 3, 1),
+		op("SetDashOffset", 0.5),
+		op("Translate", 10, 0),
+		op("MoveTo", 0, 5),
+		op("LineTo", 8, 5.12345),
+		op("Stroke"),
+	}}
+	var buf bytes.Buffer
+	err := WriteSVG(&buf, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/golden.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("svg is\n%s\nwant\n%s", buf.Bytes(), want)
+	}
+}
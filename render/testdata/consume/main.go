@@ -0,0 +1,39 @@
+// Command consume stands in for an encoder in the stream tests.
+// It reads raw rgba frames of the given size from stdin and writes the number of frames to the output file.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+func main() {
+	if len(os.Args) != 4 {
+		fmt.Fprintln(os.Stderr, "usage: consume width height output")
+		os.Exit(2)
+	}
+	width, _ := strconv.Atoi(os.Args[1])
+	height, _ := strconv.Atoi(os.Args[2])
+	file, err := os.Create(os.Args[3])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	frame := make([]byte, width*height*4)
+	frames := 0
+	for {
+		_, err := io.ReadFull(os.Stdin, frame)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "partial frame:", err)
+			os.Exit(1)
+		}
+		frames++
+	}
+	fmt.Fprintln(file, frames)
+	file.Close()
+}
@@ -3,99 +3,82 @@ package render
 
 import (
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 )
 
-// MakeGIF creates an animated gif with the given tool.
-func MakeGIF(tool, folder, outFileName string, fps float64) {
-	os.RemoveAll(outFileName)
+// MakeGIF creates an animated gif with the given tool: "convert", "ffmpeg" or "go".
+func MakeGIF(tool, folder, outFileName string, fps float64) error {
+	err := os.RemoveAll(outFileName)
+	if err != nil {
+		return &FileError{"remove", outFileName, err}
+	}
 	if tool == "convert" {
-		ConvertToGIF(folder, outFileName, fps)
+		return ConvertToGIF(folder, outFileName, fps)
 	} else if tool == "ffmpeg" {
-		FfmpegToGIF(folder, outFileName, fps)
+		return FfmpegToGIF(folder, outFileName, fps)
+	} else if tool == "go" {
+		return GoToGIF(folder, outFileName, fps)
 	}
-
+	return fmt.Errorf("render: unknown gif tool %q", tool)
 }
 
 // ConvertToGIF converts a folder of pngs into an animated gif using imagemagick convert.
-func ConvertToGIF(folder, outFileName string, fps float64) {
+func ConvertToGIF(folder, outFileName string, fps float64) error {
 	delay := fmt.Sprintf("%f", 1000.0/fps/10.0)
 	path := folder + "/*.png"
-	cmd := exec.Command("convert", "-delay", delay, "-layers", "Optimize", path, outFileName)
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err)
-	}
+	return runTool("convert", "-delay", delay, "-layers", "Optimize", path, outFileName)
 }
 
 // FfmpegToGIF converts a folder of pngs into an animated gif using ffmpeg.
-func FfmpegToGIF(folder, outFileName string, fps float64) {
+func FfmpegToGIF(folder, outFileName string, fps float64) error {
 	path := folder + "/frame_%04d.png"
 	fpsArg := fmt.Sprintf("%d", int(fps))
 
-	paletteCmd := exec.Command("ffmpeg", "-y", "-i", path, "-vf", "palettegen", "palette.png")
-	err := paletteCmd.Run()
+	tmp, err := os.MkdirTemp("", "blgg")
 	if err != nil {
-		log.Fatalf("Could not create palette: %s", err)
+		return &FileError{"mkdir", os.TempDir(), err}
 	}
+	defer os.RemoveAll(tmp)
+	palette := filepath.Join(tmp, "palette.png")
 
-	outCmd := exec.Command("ffmpeg", "-y", "-framerate", fpsArg, "-i", path, "-i", "palette.png", "-filter_complex", "paletteuse", outFileName)
-	err = outCmd.Run()
+	err = runTool("ffmpeg", "-y", "-i", path, "-vf", "palettegen", palette)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	return runTool("ffmpeg", "-y", "-framerate", fpsArg, "-i", path, "-i", palette, "-filter_complex", "paletteuse", outFileName)
 }
 
 // ConvertToYoutube converts a folder of pngs into a Youtube compatible mp4 video file. Requires ffmpeg.
-func ConvertToYoutube(folder, outFileName string, fps int) {
-	os.RemoveAll(outFileName)
-	path := folder + "/frame_%04d.png"
-	fpsArg := fmt.Sprintf("%d", fps)
-
-	cmd := exec.Command("ffmpeg", "-framerate", fpsArg, "-i", path, "-s:v", "1280x720",
-		"-c:v", "libx264", "-profile:v", "high", "-crf", "20",
-		"-pix_fmt", "yuv420p", outFileName)
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err)
-	}
+// The video keeps the size of the frames. Use ConvertToVideo for more control.
+func ConvertToYoutube(folder, outFileName string, fps int) error {
+	options := YoutubePreset
+	options.FPS = float64(fps)
+	return ConvertToVideo(folder, outFileName, options)
 }
 
 // ViewImage displays an image using installed image viewer.
-func ViewImage(imagePath string) {
-	cmd := exec.Command("eog", imagePath)
+func ViewImage(imagePath string) error {
 	if runtime.GOOS == "darwin" {
-		cmd = exec.Command("qlmanage", "-p", imagePath)
-	}
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err)
+		return runTool("qlmanage", "-p", imagePath)
 	}
+	return runTool("eog", imagePath)
 }
 
 // VLC launches vlc to play a video
-func VLC(fileName string, loop bool) {
-	loopArg := ""
+func VLC(fileName string, loop bool) error {
 	if loop {
-		loopArg = "--loop"
-	}
-	cmd := exec.Command("vlc", loopArg, fileName)
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err)
+		return runTool("vlc", "--loop", fileName)
 	}
+	return runTool("vlc", fileName)
 }
 
 // ParentDir returns the immediated directory name of the current working directory.
-func ParentDir() string {
+func ParentDir() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Cannot get directory.")
-		os.Exit(1)
+		return "", &FileError{"getwd", ".", err}
 	}
-	return filepath.Base(wd)
+	return filepath.Base(wd), nil
 }
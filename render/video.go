@@ -0,0 +1,161 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	// H264 encodes with libx264.
+	H264 = iota
+	// H265 encodes with libx265.
+	H265
+	// VP9 encodes with libvpx-vp9.
+	VP9
+	// ProRes encodes with prores_ks.
+	ProRes
+)
+
+// VideoOptions holds the settings used when converting a folder of frames into a video with ffmpeg.
+type VideoOptions struct {
+	// FPS is the frame rate of the frames. Defaults to 30.
+	FPS float64
+	// Width and Height are the output size. If both are 0 the source size is kept.
+	// If only one is set the other follows the source aspect ratio. If both are set
+	// the frames are scaled to fit and padded, never stretched.
+	Width, Height int
+	// Codec is H264, H265, VP9 or ProRes.
+	Codec int
+	// CRF is the constant rate factor, where lower is better quality. 0 uses a default for the codec.
+	// It is ignored by ProRes.
+	CRF int
+	// Bitrate, such as "8M", targets a bitrate instead of a constant quality.
+	Bitrate string
+	// PixelFormat, such as "yuv420p". Empty uses the usual format for the codec.
+	PixelFormat string
+	// Container forces an ffmpeg output format, such as "mp4" or "matroska".
+	// Empty lets ffmpeg choose from the file extension.
+	Container string
+	// Audio is the path of an audio file to add to the video. The result is cut to the shorter of the two.
+	Audio string
+	// Loops repeats the frames this many extra times.
+	Loops int
+}
+
+// YoutubePreset is an h264 mp4 at the source size, suitable for Youtube.
+var YoutubePreset = VideoOptions{FPS: 30, Codec: H264, CRF: 20}
+
+// InstagramPreset is a square 1080 x 1080 h264 mp4.
+var InstagramPreset = VideoOptions{FPS: 30, Width: 1080, Height: 1080, Codec: H264, CRF: 20}
+
+// TwitterPreset is a 1280 x 720 h264 mp4 within Twitter's bitrate limits.
+var TwitterPreset = VideoOptions{FPS: 30, Width: 1280, Height: 720, Codec: H264, Bitrate: "5M"}
+
+// WebPreset is a vp9 video at the source size, for webm files.
+var WebPreset = VideoOptions{FPS: 30, Codec: VP9, CRF: 31}
+
+// ProResPreset is a ProRes HQ video at the source size, for mov files that will be edited further.
+var ProResPreset = VideoOptions{FPS: 30, Codec: ProRes}
+
+// ConvertToVideo converts a folder of pngs into a video file. Requires ffmpeg.
+func ConvertToVideo(folder, outFileName string, options VideoOptions) error {
+	err := os.RemoveAll(outFileName)
+	if err != nil {
+		return &FileError{"remove", outFileName, err}
+	}
+	args := VideoCommand(folder, outFileName, options)
+	return runTool(args[0], args[1:]...)
+}
+
+// VideoCommand returns the ffmpeg command line that ConvertToVideo would run, without running it.
+func VideoCommand(folder, outFileName string, options VideoOptions) []string {
+	fps := options.FPS
+	if fps <= 0 {
+		fps = 30
+	}
+	args := []string{"ffmpeg", "-y", "-framerate", strconv.FormatFloat(fps, 'f', -1, 64)}
+	if options.Loops > 0 {
+		args = append(args, "-stream_loop", strconv.Itoa(options.Loops))
+	}
+	args = append(args, "-i", folder+"/frame_%04d.png")
+	if options.Audio != "" {
+		args = append(args, "-i", options.Audio, "-map", "0:v", "-map", "1:a", "-shortest")
+	}
+	args = append(args, "-vf", scaleFilter(options.Width, options.Height))
+
+	pixelFormat := options.PixelFormat
+	switch options.Codec {
+	case H265:
+		args = append(args, "-c:v", "libx265", "-tag:v", "hvc1")
+		args = appendQuality(args, options, 24)
+	case VP9:
+		args = append(args, "-c:v", "libvpx-vp9")
+		if options.Bitrate == "" {
+			// vp9 only uses constant quality mode when the bitrate is zero.
+			args = append(args, "-b:v", "0")
+		}
+		args = appendQuality(args, options, 31)
+	case ProRes:
+		args = append(args, "-c:v", "prores_ks", "-profile:v", "3")
+		if pixelFormat == "" {
+			pixelFormat = "yuv422p10le"
+		}
+	default:
+		args = append(args, "-c:v", "libx264")
+		if pixelFormat == "" || pixelFormat == "yuv420p" {
+			args = append(args, "-profile:v", "high")
+		}
+		args = appendQuality(args, options, 20)
+	}
+	if pixelFormat == "" {
+		pixelFormat = "yuv420p"
+	}
+	args = append(args, "-pix_fmt", pixelFormat)
+
+	if options.Audio != "" {
+		args = append(args, "-c:a", audioCodec(options.Codec))
+	}
+	if options.Container != "" {
+		args = append(args, "-f", options.Container)
+	}
+	return append(args, outFileName)
+}
+
+// scaleFilter builds an ffmpeg filter that sizes the video without distorting it.
+// Sizes are kept even, as most pixel formats require.
+func scaleFilter(width, height int) string {
+	if width > 0 && height > 0 {
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2", width, height, width, height)
+	}
+	if width > 0 {
+		return fmt.Sprintf("scale=%d:-2", width)
+	}
+	if height > 0 {
+		return fmt.Sprintf("scale=-2:%d", height)
+	}
+	return "scale=trunc(iw/2)*2:trunc(ih/2)*2"
+}
+
+// appendQuality adds either the bitrate or the crf, falling back to the codec's default crf.
+func appendQuality(args []string, options VideoOptions, defaultCRF int) []string {
+	if options.Bitrate != "" {
+		return append(args, "-b:v", options.Bitrate)
+	}
+	crf := options.CRF
+	if crf <= 0 {
+		crf = defaultCRF
+	}
+	return append(args, "-crf", strconv.Itoa(crf))
+}
+
+// audioCodec picks an audio codec that suits the video codec's usual container.
+func audioCodec(codec int) string {
+	switch codec {
+	case VP9:
+		return "libopus"
+	case ProRes:
+		return "pcm_s16le"
+	}
+	return "aac"
+}
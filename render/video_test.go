@@ -0,0 +1,33 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVideoCommand(t *testing.T) {
+	withAudio := YoutubePreset
+	withAudio.Loops = 2
+	withAudio.Audio = "music.wav"
+	tests := []struct {
+		name    string
+		options VideoOptions
+		want    string
+	}{
+		{"youtube", YoutubePreset, "-framerate 30 -i f/frame_%04d.png -vf scale=trunc(iw/2)*2:trunc(ih/2)*2 -c:v libx264 -profile:v high -crf 20 -pix_fmt yuv420p"},
+		{"instagram", InstagramPreset, "-framerate 30 -i f/frame_%04d.png -vf scale=1080:1080:force_original_aspect_ratio=decrease,pad=1080:1080:(ow-iw)/2:(oh-ih)/2 -c:v libx264 -profile:v high -crf 20 -pix_fmt yuv420p"},
+		{"twitter", TwitterPreset, "-framerate 30 -i f/frame_%04d.png -vf scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2 -c:v libx264 -profile:v high -b:v 5M -pix_fmt yuv420p"},
+		{"web", WebPreset, "-framerate 30 -i f/frame_%04d.png -vf scale=trunc(iw/2)*2:trunc(ih/2)*2 -c:v libvpx-vp9 -b:v 0 -crf 31 -pix_fmt yuv420p"},
+		{"prores", ProResPreset, "-framerate 30 -i f/frame_%04d.png -vf scale=trunc(iw/2)*2:trunc(ih/2)*2 -c:v prores_ks -profile:v 3 -pix_fmt yuv422p10le"},
+		{"loops and audio", withAudio, "-framerate 30 -stream_loop 2 -i f/frame_%04d.png -i music.wav -map 0:v -map 1:a -shortest -vf scale=trunc(iw/2)*2:trunc(ih/2)*2 -c:v libx264 -profile:v high -crf 20 -pix_fmt yuv420p -c:a aac"},
+		{"h265 in matroska", VideoOptions{Codec: H265, Width: 640, Container: "matroska"}, "-framerate 30 -i f/frame_%04d.png -vf scale=640:-2 -c:v libx265 -tag:v hvc1 -crf 24 -pix_fmt yuv420p -f matroska"},
+	}
+	for _, test := range tests {
+		got := VideoCommand("f", "out.mp4", test.options)
+		want := append(append([]string{"ffmpeg", "-y"}, strings.Fields(test.want)...), "out.mp4")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\ngot  %v\nwant %v", test.name, got, want)
+		}
+	}
+}
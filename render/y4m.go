@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"os"
+)
+
+// Y4MOptions holds the settings used when writing a YUV4MPEG2 video.
+type Y4MOptions struct {
+	Options
+	// FPS is the playback rate. Defaults to 30.
+	FPS float64
+}
+
+// Y4M renders a series of frames into an uncompressed YUV4MPEG2 (.y4m) video, without external tools.
+// Any encoder that reads y4m can turn the result into a compressed video later.
+func Y4M(width, height float64, numFrames int, path string, frameFunc FrameFunc, options Y4MOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return &FileError{"create", path, err}
+	}
+	y4m := NewY4MWriter(file, int(width), int(height), options.FPS)
+	r := newRenderer(width, height, frameFunc, options.Options)
+	for frame := 0; frame < numFrames; frame++ {
+		percent, duration := options.frameTime(frame, numFrames)
+		fmt.Printf("\r%f", float64(frame)/float64(numFrames))
+		err = y4m.WriteFrame(r.frame(percent, duration))
+		if err != nil {
+			fmt.Println()
+			file.Close()
+			return &EncodeError{"y4m", err}
+		}
+	}
+	err = y4m.Flush()
+	if err != nil {
+		file.Close()
+		return &EncodeError{"y4m", err}
+	}
+	err = file.Close()
+	if err != nil {
+		return &FileError{"close", path, err}
+	}
+	fmt.Println("\nDone!")
+	return nil
+}
+
+// Y4MWriter streams frames to a writer as 4:2:0 YUV4MPEG2 video.
+type Y4MWriter struct {
+	w             *bufio.Writer
+	width, height int
+	fps           float64
+	started       bool
+	rgba          *image.RGBA
+	buf           []byte
+}
+
+// NewY4MWriter creates a Y4MWriter for frames of the given size.
+func NewY4MWriter(w io.Writer, width, height int, fps float64) *Y4MWriter {
+	if fps <= 0 {
+		fps = 30
+	}
+	cw, ch := (width+1)/2, (height+1)/2
+	return &Y4MWriter{
+		w:      bufio.NewWriter(w),
+		width:  width,
+		height: height,
+		fps:    fps,
+		rgba:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		buf:    make([]byte, width*height+2*cw*ch),
+	}
+}
+
+// WriteFrame converts an image to limited range bt.601 yuv and writes it as the next frame.
+// The header is written before the first frame.
+func (y *Y4MWriter) WriteFrame(img image.Image) error {
+	if !y.started {
+		num, den := frameRate(y.fps)
+		_, err := fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C420jpeg\n", y.width, y.height, num, den)
+		if err != nil {
+			return err
+		}
+		y.started = true
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Bounds() != y.rgba.Bounds() {
+		draw.Draw(y.rgba, y.rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		rgba = y.rgba
+	}
+	rgbToYUV420(rgba, y.buf)
+	_, err := y.w.WriteString("FRAME\n")
+	if err != nil {
+		return err
+	}
+	_, err = y.w.Write(y.buf)
+	return err
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (y *Y4MWriter) Flush() error {
+	return y.w.Flush()
+}
+
+// frameRate expresses fps as a ratio of integers. Whole frame rates are exact.
+func frameRate(fps float64) (int, int) {
+	if fps == math.Trunc(fps) {
+		return int(fps), 1
+	}
+	return int(math.Round(fps * 1000)), 1000
+}
+
+// rgbToYUV420 fills buf with the y plane followed by the 2x2 averaged u and v planes.
+// Premultiplied pixels are treated as if composited over black.
+func rgbToYUV420(img *image.RGBA, buf []byte) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	cw, ch := (w+1)/2, (h+1)/2
+	yPlane := buf[:w*h]
+	uPlane := buf[w*h : w*h+cw*ch]
+	vPlane := buf[w*h+cw*ch:]
+
+	for py := 0; py < h; py++ {
+		i := img.PixOffset(0, py)
+		for px := 0; px < w; px++ {
+			r, g, b := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])
+			yPlane[py*w+px] = uint8(16.5 + (65.481*r+128.553*g+24.966*b)/255)
+			i += 4
+		}
+	}
+	for cy := 0; cy < ch; cy++ {
+		for cx := 0; cx < cw; cx++ {
+			var r, g, b, n float64
+			for py := cy * 2; py < cy*2+2 && py < h; py++ {
+				for px := cx * 2; px < cx*2+2 && px < w; px++ {
+					i := img.PixOffset(px, py)
+					r += float64(img.Pix[i])
+					g += float64(img.Pix[i+1])
+					b += float64(img.Pix[i+2])
+					n++
+				}
+			}
+			r, g, b = r/n, g/n, b/n
+			uPlane[cy*cw+cx] = uint8(128.5 + (-37.797*r-74.203*g+112*b)/255)
+			vPlane[cy*cw+cx] = uint8(128.5 + (112*r-93.786*g-18.214*b)/255)
+		}
+	}
+}
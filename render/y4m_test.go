@@ -0,0 +1,52 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestY4MWriter(t *testing.T) {
+	// a 3x3 frame has 2x2 chroma planes, the last row and column averaging a single pixel.
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	img.Set(2, 2, color.RGBA{255, 0, 0, 255})
+	img.Set(0, 0, color.RGBA{255, 255, 255, 255})
+
+	var b bytes.Buffer
+	y4m := NewY4MWriter(&b, 3, 3, 29.97)
+	for i := 0; i < 2; i++ {
+		err := y4m.WriteFrame(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := y4m.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, data, ok := bytes.Cut(b.Bytes(), []byte("\n"))
+	if !ok || string(header) != "YUV4MPEG2 W3 H3 F29970:1000 Ip A1:1 C420jpeg" {
+		t.Fatalf("header %q", header)
+	}
+	frameSize := len("FRAME\n") + 9 + 2*4
+	if len(data) != 2*frameSize {
+		t.Fatalf("%d bytes of frames, want 2 frames of %d", len(data), frameSize)
+	}
+	if !bytes.HasPrefix(data[frameSize:], []byte("FRAME\n")) {
+		t.Fatal("second frame does not start with FRAME")
+	}
+	frame := data[len("FRAME\n"):frameSize]
+	yPlane, uPlane, vPlane := frame[:9], frame[9:13], frame[13:]
+	// limited range bt.601: white is 235, black 16 and red 81 in y, and red is 90, 240 in u and v.
+	if yPlane[0] != 235 || yPlane[1] != 16 || yPlane[8] != 81 {
+		t.Errorf("y plane %v", yPlane)
+	}
+	if uPlane[1] != 128 || vPlane[1] != 128 || uPlane[3] != 90 || vPlane[3] != 240 {
+		t.Errorf("u plane %v, v plane %v", uPlane, vPlane)
+	}
+}
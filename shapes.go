@@ -5,7 +5,6 @@ import (
 	"math"
 
 	"github.com/bit101/bitlib/geom"
-	"github.com/bit101/bitlib/random"
 )
 
 ////////////////////
@@ -24,6 +23,13 @@ func (c *Context) StrokeArc(x, y, r, a1, a2 float64) {
 	c.Stroke()
 }
 
+// ArcPath returns a circular arc as a path.
+func ArcPath(x, y, r, a1, a2 float64) Path {
+	var p Path
+	p.DrawArc(x, y, r, a1, a2)
+	return p
+}
+
 ////////////////////
 // ARROW
 ////////////////////
@@ -89,6 +95,11 @@ func (c *Context) StrokeCircle(x, y, r float64) {
 	c.Stroke()
 }
 
+// CirclePath returns a circle as a closed path.
+func CirclePath(x, y, r float64) Path {
+	return EllipsePath(x, y, r, r)
+}
+
 ////////////////////
 // ELLIPSE
 ////////////////////
@@ -105,6 +116,14 @@ func (c *Context) StrokeEllipse(x, y, rx, ry float64) {
 	c.Stroke()
 }
 
+// EllipsePath returns an ellipse as a closed path.
+func EllipsePath(x, y, rx, ry float64) Path {
+	var p Path
+	p.DrawEllipticalArc(x, y, rx, ry, 0, 2*math.Pi)
+	p.ClosePath()
+	return p
+}
+
 ////////////////////
 // ELLIPTICAL ARC
 ////////////////////
@@ -127,6 +146,17 @@ func (c *Context) StrokeEllipticalArc(x, y, rx, ry, a1, a2 float64) {
 
 // FractalLine draws a rough, fractal line between two points.
 func (c *Context) FractalLine(x1, y1, x2, y2, roughness float64, iterations int) {
+	c.Path(c.fractalPoints(x1, y1, x2, y2, roughness, iterations))
+}
+
+// FractalLinePath returns a rough, fractal line between two points as a path.
+// Like FractalLine, it uses the context's random numbers.
+func (c *Context) FractalLinePath(x1, y1, x2, y2, roughness float64, iterations int) Path {
+	return PolylinePath(c.fractalPoints(x1, y1, x2, y2, roughness, iterations), false)
+}
+
+// fractalPoints returns the points of a fractal line, splitting each line at a randomly moved midpoint.
+func (c *Context) fractalPoints(x1, y1, x2, y2, roughness float64, iterations int) []*geom.Point {
 	dx := x2 - x1
 	dy := y2 - y1
 	offset := math.Sqrt(dx*dx+dy*dy) * 0.15
@@ -141,15 +171,15 @@ func (c *Context) FractalLine(x1, y1, x2, y2, roughness float64, iterations int)
 			newPath = append(newPath, geom.NewPoint(point.X, point.Y))
 			if j < len(path)-1 {
 				mid := geom.MidPoint(point, path[j+1])
-				mid.X += random.FloatRange(-offset, offset)
-				mid.Y += random.FloatRange(-offset, offset)
+				mid.X += c.RandomRange(-offset, offset)
+				mid.Y += c.RandomRange(-offset, offset)
 				newPath = append(newPath, mid)
 			}
 		}
 		offset *= roughness
 		path = newPath
 	}
-	c.Path(path)
+	return path
 }
 
 // StrokeFractalLine draws a fractal line between two points and strokes it.
@@ -211,6 +241,22 @@ func (c *Context) Heart(x, y, w, h, r float64) {
 	c.Push()
 	c.Translate(x, y)
 	c.Rotate(r)
+	c.Path(heartPoints(w, h))
+	c.Pop()
+}
+
+// HeartPath returns a heart shape as a closed path.
+func HeartPath(x, y, w, h, r float64) Path {
+	points := heartPoints(w, h)
+	cos, sin := math.Cos(r), math.Sin(r)
+	for _, p := range points {
+		p.X, p.Y = x+p.X*cos-p.Y*sin, y+p.X*sin+p.Y*cos
+	}
+	return PolylinePath(points, true)
+}
+
+// heartPoints returns the points of a heart shape centered on the origin.
+func heartPoints(w, h float64) []*geom.Point {
 	var path []*geom.Point
 	res := math.Sqrt(w * h)
 	for i := 0.0; i < res; i++ {
@@ -219,8 +265,7 @@ func (c *Context) Heart(x, y, w, h, r float64) {
 		y := h * (0.8125*math.Cos(a) - 0.3125*math.Cos(2.0*a) - 0.125*math.Cos(3.0*a) - 0.0625*math.Cos(4.0*a))
 		path = append(path, geom.NewPoint(x, -y))
 	}
-	c.Path(path)
-	c.Pop()
+	return path
 }
 
 // FillHeart draws a heart shape and fills it.
@@ -309,20 +354,24 @@ func (c *Context) LineThrough(x0, y0, x1, y1, overlap float64) {
 
 // MultiCurve draws a piecewise bezier curve through a series of points.
 func (c *Context) MultiCurve(points []*geom.Point) {
-	c.MoveTo(points[0].X, points[0].Y)
+	MultiCurvePath(points).Draw(c)
+}
+
+// MultiCurvePath returns a piecewise bezier curve through a series of points as a path.
+func MultiCurvePath(points []*geom.Point) Path {
+	var path Path
+	path.MoveTo(points[0].X, points[0].Y)
 	mid := geom.MidPoint(points[0], points[1])
-	c.LineTo(mid.X, mid.Y)
-	i := 1
-	for i < len(points)-1 {
+	path.LineTo(mid.X, mid.Y)
+	for i := 1; i < len(points)-1; i++ {
 		p0 := points[i]
 		p1 := points[i+1]
 		mid := geom.MidPoint(p0, p1)
-		c.QuadraticTo(p0.X, p0.Y, mid.X, mid.Y)
-		i++
-
+		path.QuadraticTo(p0.X, p0.Y, mid.X, mid.Y)
 	}
 	p := points[len(points)-1]
-	c.LineTo(p.X, p.Y)
+	path.LineTo(p.X, p.Y)
+	return path
 }
 
 // StrokeMultiCurve draws a multi curve and strokes it.
@@ -337,17 +386,25 @@ func (c *Context) StrokeMultiCurve(points []*geom.Point) {
 
 // MultiLoop draws a closed piecewise bezier curve through a series of points.
 func (c *Context) MultiLoop(points []*geom.Point) {
+	MultiLoopPath(points).Draw(c)
+}
+
+// MultiLoopPath returns a closed piecewise bezier curve through a series of points as a path.
+func MultiLoopPath(points []*geom.Point) Path {
+	var path Path
 	pA := points[0]
 	pZ := points[len(points)-1]
 	mid1 := geom.MidPoint(pZ, pA)
-	c.MoveTo(mid1.X, mid1.Y)
+	path.MoveTo(mid1.X, mid1.Y)
 	for i := 0; i < len(points)-1; i++ {
 		p0 := points[i]
 		p1 := points[i+1]
 		mid := geom.MidPoint(p0, p1)
-		c.QuadraticTo(p0.X, p0.Y, mid.X, mid.Y)
+		path.QuadraticTo(p0.X, p0.Y, mid.X, mid.Y)
 	}
-	c.QuadraticTo(pZ.X, pZ.Y, mid1.X, mid1.Y)
+	path.QuadraticTo(pZ.X, pZ.Y, mid1.X, mid1.Y)
+	path.ClosePath()
+	return path
 }
 
 // FillMultiLoop draws a filled, smooth, closed curve between a set of points.
@@ -373,6 +430,18 @@ func (c *Context) Path(points []*geom.Point) {
 	}
 }
 
+// PolylinePath returns a series of lines through a set of points as a path, closed if close is true.
+func PolylinePath(points []*geom.Point, close bool) Path {
+	var path Path
+	for _, point := range points {
+		path.LineTo(point.X, point.Y)
+	}
+	if close {
+		path.ClosePath()
+	}
+	return path
+}
+
 // FillPath draws a path and fills it.
 func (c *Context) FillPath(points []*geom.Point) {
 	c.Path(points)
@@ -442,6 +511,17 @@ func (c *Context) StrokeRectangle(x, y, w, h float64) {
 	c.Stroke()
 }
 
+// RectanglePath returns a rectangle as a closed path.
+func RectanglePath(x, y, w, h float64) Path {
+	var path Path
+	path.MoveTo(x, y)
+	path.LineTo(x+w, y)
+	path.LineTo(x+w, y+h)
+	path.LineTo(x, y+h)
+	path.ClosePath()
+	return path
+}
+
 ////////////////////
 // REGULAR POLYGON
 ////////////////////
@@ -458,6 +538,22 @@ func (c *Context) StrokeRegularPolygon(n int, x, y, r, rot float64) {
 	c.Stroke()
 }
 
+// RegularPolygonPath returns a regular polygon as a closed path, with the same corners as DrawRegularPolygon.
+func RegularPolygonPath(n int, x, y, r, rot float64) Path {
+	var path Path
+	angle := 2 * math.Pi / float64(n)
+	rot -= math.Pi / 2
+	if n%2 == 0 {
+		rot += angle / 2
+	}
+	for i := 0; i < n; i++ {
+		a := rot + angle*float64(i)
+		path.LineTo(x+r*math.Cos(a), y+r*math.Sin(a))
+	}
+	path.ClosePath()
+	return path
+}
+
 ////////////////////
 // RIGHT TRIANGLE
 ////////////////////
@@ -523,6 +619,21 @@ func (c *Context) Star(x, y, r0, r1 float64, points int, rotation float64) {
 	c.Pop()
 }
 
+// StarPath returns a star shape as a closed path.
+func StarPath(x, y, r0, r1 float64, points int, rotation float64) Path {
+	var path Path
+	for i := 0; i < points*2; i++ {
+		r := r1
+		if i%2 == 1 {
+			r = r0
+		}
+		angle := math.Pi/float64(points)*float64(i) + rotation
+		path.LineTo(x+math.Cos(angle)*r, y+math.Sin(angle)*r)
+	}
+	path.ClosePath()
+	return path
+}
+
 // StrokeStar draws a star and strokes it.
 func (c *Context) StrokeStar(x, y, r0, r1 float64, points int, rotation float64) {
 	c.Star(x, y, r0, r1, points, rotation)
@@ -0,0 +1,378 @@
+// Package blgg is the main package for this module.
+package blgg
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/geom"
+	"github.com/fogleman/gg"
+)
+
+// Shape is a path painted by a display list, with the settings it was painted with.
+// Points are in the units of the display list, with every transform already applied.
+type Shape struct {
+	Path Path
+	// Stroke is true for stroked paths and false for filled ones.
+	Stroke     bool
+	Color      blcolor.Color
+	LineWidth  float64
+	LineCap    gg.LineCap
+	LineJoin   gg.LineJoin
+	FillRule   gg.FillRule
+	Dash       []float64
+	DashOffset float64
+	// Clip lists the regions the shape is limited to. It is drawn only where it is inside all of them.
+	// Shapes drawn under the same clip share the same *Clip values.
+	Clip []*Clip
+}
+
+// Clip is a region that limits drawing.
+type Clip struct {
+	Path     Path
+	FillRule gg.FillRule
+}
+
+// Shapes plays the list the way a Context would draw it and returns the painted paths in order,
+// for writing to vector formats. A Clear removes the shapes before it, as it would cover them.
+func (d *DisplayList) Shapes() ([]Shape, error) {
+	p := &player{
+		width:  d.Width,
+		height: d.Height,
+		state: vectorState{
+			matrix:    identity,
+			color:     blcolor.Black(),
+			lineWidth: 1,
+		},
+	}
+	for i, op := range d.Ops {
+		err := op.check(i)
+		if err != nil {
+			return nil, err
+		}
+		err = p.play(op)
+		if err != nil {
+			return nil, fmt.Errorf("blgg: %s at %d: %w", op.Name, i, err)
+		}
+	}
+	return p.shapes, nil
+}
+
+// //////////////////
+// MATRIX
+// //////////////////
+
+// matrix is an affine transform mapping x, y to a*x + c*y + e, b*x + d*y + f.
+type matrix struct {
+	a, b, c, d, e, f float64
+}
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// then returns a matrix that applies n and then m, the way gg adds a transform.
+func (m matrix) then(n matrix) matrix {
+	return matrix{
+		m.a*n.a + m.c*n.b,
+		m.b*n.a + m.d*n.b,
+		m.a*n.c + m.c*n.d,
+		m.b*n.c + m.d*n.d,
+		m.a*n.e + m.c*n.f + m.e,
+		m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+func (m matrix) apply(x, y float64) geom.Point {
+	return geom.Point{X: m.a*x + m.c*y + m.e, Y: m.b*x + m.d*y + m.f}
+}
+
+func translation(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+func rotation(angle float64) matrix {
+	s, c := math.Sin(angle), math.Cos(angle)
+	return matrix{c, s, -s, c, 0, 0}
+}
+
+func scaling(x, y float64) matrix {
+	return matrix{x, 0, 0, y, 0, 0}
+}
+
+// //////////////////
+// PLAYER
+// //////////////////
+
+// vectorState is the part of a context's state that Push saves and Pop restores.
+type vectorState struct {
+	matrix     matrix
+	color      blcolor.Color
+	lineWidth  float64
+	lineCap    gg.LineCap
+	lineJoin   gg.LineJoin
+	fillRule   gg.FillRule
+	dash       []float64
+	dashOffset float64
+}
+
+// player follows gg's rules for paths: points are transformed as they are added,
+// and the path and clip are kept across Push and Pop.
+type player struct {
+	width, height float64
+	state         vectorState
+	stack         []vectorState
+	path          Path
+	start         geom.Point
+	hasCurrent    bool
+	clip          []*Clip
+	shapes        []Shape
+}
+
+func (p *player) play(op Op) error {
+	a := op.Args
+	m := p.state.matrix
+	switch op.Name {
+	case "MoveTo":
+		p.moveTo(m.apply(a[0], a[1]))
+	case "LineTo":
+		p.lineTo(m.apply(a[0], a[1]))
+	case "QuadraticTo":
+		p.quadTo(m.apply(a[0], a[1]), m.apply(a[2], a[3]))
+	case "CubicTo":
+		p.cubicTo(m.apply(a[0], a[1]), m.apply(a[2], a[3]), m.apply(a[4], a[5]))
+	case "ClosePath":
+		p.closePath()
+	case "ClearPath":
+		p.path = Path{}
+		p.hasCurrent = false
+	case "NewSubPath":
+		p.hasCurrent = false
+
+	case "DrawPoint":
+		// the radius is not transformed, only the center.
+		center := m.apply(a[0], a[1])
+		p.hasCurrent = false
+		p.arc(identity, center.X, center.Y, a[2], a[2], 0, 2*math.Pi)
+		p.closePath()
+	case "DrawLine":
+		p.moveTo(m.apply(a[0], a[1]))
+		p.lineTo(m.apply(a[2], a[3]))
+	case "DrawRectangle":
+		x, y, w, h := a[0], a[1], a[2], a[3]
+		p.hasCurrent = false
+		p.moveTo(m.apply(x, y))
+		p.lineTo(m.apply(x+w, y))
+		p.lineTo(m.apply(x+w, y+h))
+		p.lineTo(m.apply(x, y+h))
+		p.closePath()
+	case "DrawRoundedRectangle":
+		x, y, w, h, r := a[0], a[1], a[2], a[3], a[4]
+		x1, x2, y1, y2 := x+r, x+w-r, y+r, y+h-r
+		p.hasCurrent = false
+		p.moveTo(m.apply(x1, y))
+		p.lineTo(m.apply(x2, y))
+		p.arc(m, x2, y1, r, r, -math.Pi/2, 0)
+		p.lineTo(m.apply(x+w, y2))
+		p.arc(m, x2, y2, r, r, 0, math.Pi/2)
+		p.lineTo(m.apply(x1, y+h))
+		p.arc(m, x1, y2, r, r, math.Pi/2, math.Pi)
+		p.lineTo(m.apply(x, y1))
+		p.arc(m, x1, y1, r, r, math.Pi, math.Pi*1.5)
+		p.closePath()
+	case "DrawCircle":
+		p.hasCurrent = false
+		p.arc(m, a[0], a[1], a[2], a[2], 0, 2*math.Pi)
+		p.closePath()
+	case "DrawArc":
+		p.arc(m, a[0], a[1], a[2], a[2], a[3], a[4])
+	case "DrawEllipse":
+		p.hasCurrent = false
+		p.arc(m, a[0], a[1], a[2], a[3], 0, 2*math.Pi)
+		p.closePath()
+	case "DrawEllipticalArc":
+		p.arc(m, a[0], a[1], a[2], a[3], a[4], a[5])
+	case "DrawRegularPolygon":
+		n := int(a[0])
+		x, y, r := a[1], a[2], a[3]
+		angle := 2 * math.Pi / float64(n)
+		rotation := a[4] - math.Pi/2
+		if n%2 == 0 {
+			rotation += angle / 2
+		}
+		p.hasCurrent = false
+		for i := 0; i < n; i++ {
+			t := rotation + angle*float64(i)
+			p.lineTo(m.apply(x+r*math.Cos(t), y+r*math.Sin(t)))
+		}
+		p.closePath()
+
+	case "Fill", "FillPreserve", "Stroke", "StrokePreserve":
+		p.paint(op.Name == "Stroke" || op.Name == "StrokePreserve")
+		if op.Name == "Fill" || op.Name == "Stroke" {
+			p.path = Path{}
+			p.hasCurrent = false
+		}
+	case "Clip", "ClipPreserve":
+		clip := &Clip{Path: p.copyPath(), FillRule: p.state.fillRule}
+		// a new slice, so shapes already painted keep their own clip list.
+		p.clip = append(p.clip[:len(p.clip):len(p.clip)], clip)
+		if op.Name == "Clip" {
+			p.path = Path{}
+			p.hasCurrent = false
+		}
+	case "ResetClip":
+		p.clip = nil
+	case "Clear":
+		// clearing ignores the transform and clip, and covers everything drawn so far.
+		p.shapes = p.shapes[:0]
+		p.pixel(0, 0, p.width, p.height)
+	case "SetPixel", "SetPixelF":
+		p.pixel(math.Floor(a[0]), math.Floor(a[1]), 1, 1)
+
+	case "Push":
+		p.stack = append(p.stack, p.state)
+	case "Pop":
+		if len(p.stack) == 0 {
+			return fmt.Errorf("pop without push")
+		}
+		p.state = p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+	case "Identity":
+		p.state.matrix = identity
+	case "Translate":
+		p.state.matrix = m.then(translation(a[0], a[1]))
+	case "Rotate":
+		p.state.matrix = m.then(rotation(a[0]))
+	case "RotateAbout":
+		p.state.matrix = m.then(translation(a[1], a[2])).then(rotation(a[0])).then(translation(-a[1], -a[2]))
+	case "Scale":
+		p.state.matrix = m.then(scaling(a[0], a[1]))
+	case "ScaleAbout":
+		p.state.matrix = m.then(translation(a[2], a[3])).then(scaling(a[0], a[1])).then(translation(-a[2], -a[3]))
+	case "Shear":
+		p.state.matrix = m.then(matrix{1, a[1], a[0], 1, 0, 0})
+	case "InvertY":
+		p.state.matrix = m.then(translation(0, p.height)).then(scaling(1, -1))
+
+	case "SetRGBA":
+		p.state.color = blcolor.RGBA(a[0], a[1], a[2], a[3])
+	case "SetLineWidth":
+		p.state.lineWidth = a[0]
+	case "SetLineCap":
+		p.state.lineCap = gg.LineCap(a[0])
+	case "SetLineJoin":
+		p.state.lineJoin = gg.LineJoin(a[0])
+	case "SetFillRule":
+		p.state.fillRule = gg.FillRule(a[0])
+	case "SetDash":
+		p.state.dash = append([]float64(nil), a...)
+	case "SetDashOffset":
+		p.state.dashOffset = a[0]
+	}
+	return nil
+}
+
+func (p *player) add(kind int, points ...geom.Point) {
+	p.path.Segments = append(p.path.Segments, Segment{Kind: kind, Points: points})
+}
+
+func (p *player) moveTo(pt geom.Point) {
+	p.add(SegmentMove, pt)
+	p.start = pt
+	p.hasCurrent = true
+}
+
+func (p *player) lineTo(pt geom.Point) {
+	if !p.hasCurrent {
+		p.moveTo(pt)
+		return
+	}
+	p.add(SegmentLine, pt)
+}
+
+func (p *player) quadTo(p1, p2 geom.Point) {
+	if !p.hasCurrent {
+		p.moveTo(p1)
+	}
+	p.add(SegmentQuad, p1, p2)
+}
+
+func (p *player) cubicTo(p1, p2, p3 geom.Point) {
+	if !p.hasCurrent {
+		p.moveTo(p1)
+	}
+	p.add(SegmentCubic, p1, p2, p3)
+}
+
+func (p *player) closePath() {
+	if p.hasCurrent {
+		p.add(SegmentClose)
+	}
+}
+
+// arc adds an elliptical arc made of cubic curves, each spanning at most a quarter turn.
+// Like gg, it starts with a line from the current point if there is one.
+func (p *player) arc(m matrix, x, y, rx, ry, a1, a2 float64) {
+	n := int(math.Ceil(math.Abs(a2-a1) / (math.Pi / 2)))
+	if n < 1 {
+		n = 1
+	}
+	step := (a2 - a1) / float64(n)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+	p.lineTo(m.apply(x+rx*math.Cos(a1), y+ry*math.Sin(a1)))
+	for i := 0; i < n; i++ {
+		t1 := a1 + step*float64(i)
+		t2 := t1 + step
+		cos1, sin1 := math.Cos(t1), math.Sin(t1)
+		cos2, sin2 := math.Cos(t2), math.Sin(t2)
+		p.cubicTo(
+			m.apply(x+rx*(cos1-k*sin1), y+ry*(sin1+k*cos1)),
+			m.apply(x+rx*(cos2+k*sin2), y+ry*(sin2-k*cos2)),
+			m.apply(x+rx*cos2, y+ry*sin2),
+		)
+	}
+}
+
+// paint adds the current path as a shape, if it draws anything.
+func (p *player) paint(stroke bool) {
+	drawn := false
+	for _, s := range p.path.Segments {
+		if s.Kind != SegmentMove {
+			drawn = true
+			break
+		}
+	}
+	if !drawn {
+		return
+	}
+	p.shapes = append(p.shapes, Shape{
+		Path:       p.copyPath(),
+		Stroke:     stroke,
+		Color:      p.state.color,
+		LineWidth:  p.state.lineWidth,
+		LineCap:    p.state.lineCap,
+		LineJoin:   p.state.lineJoin,
+		FillRule:   p.state.fillRule,
+		Dash:       p.state.dash,
+		DashOffset: p.state.dashOffset,
+		Clip:       p.clip,
+	})
+}
+
+// pixel adds an untransformed, unclipped filled rectangle, as Clear and SetPixel draw.
+func (p *player) pixel(x, y, w, h float64) {
+	p.shapes = append(p.shapes, Shape{
+		Path: Path{Segments: []Segment{
+			{Kind: SegmentMove, Points: []geom.Point{{X: x, Y: y}}},
+			{Kind: SegmentLine, Points: []geom.Point{{X: x + w, Y: y}}},
+			{Kind: SegmentLine, Points: []geom.Point{{X: x + w, Y: y + h}}},
+			{Kind: SegmentLine, Points: []geom.Point{{X: x, Y: y + h}}},
+			{Kind: SegmentClose},
+		}},
+		Color: p.state.color,
+	})
+}
+
+func (p *player) copyPath() Path {
+	return Path{Segments: append([]Segment(nil), p.path.Segments...)}
+}
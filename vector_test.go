@@ -0,0 +1,146 @@
+package blgg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+func near(a, b geom.Point) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+func op(name string, args ...float64) Op {
+	return Op{Name: name, Args: args}
+}
+
+func shapes(t *testing.T, ops ...Op) []Shape {
+	t.Helper()
+	list := &DisplayList{Width: 10, Height: 10, Ops: ops}
+	shapes, err := list.Shapes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shapes
+}
+
+func TestShapesTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Op
+		want geom.Point
+	}{
+		{"identity", nil, geom.Point{X: 1, Y: 2}},
+		{"translate then scale", []Op{op("Translate", 10, 20), op("Scale", 2, 3)}, geom.Point{X: 12, Y: 26}},
+		{"scale then translate", []Op{op("Scale", 2, 3), op("Translate", 10, 20)}, geom.Point{X: 22, Y: 66}},
+		{"rotate", []Op{op("Rotate", math.Pi/2)}, geom.Point{X: -2, Y: 1}},
+		{"rotate about", []Op{op("RotateAbout", math.Pi/2, 5, 5)}, geom.Point{X: 8, Y: 1}},
+		{"scale about", []Op{op("ScaleAbout", 2, 2, 5, 5)}, geom.Point{X: -3, Y: -1}},
+		{"shear", []Op{op("Shear", 1, 0)}, geom.Point{X: 3, Y: 2}},
+		{"invert y", []Op{op("InvertY")}, geom.Point{X: 1, Y: 8}},
+		{"push pop", []Op{op("Push"), op("Translate", 5, 5), op("Pop")}, geom.Point{X: 1, Y: 2}},
+		{"identity resets", []Op{op("Scale", 4, 4), op("Identity")}, geom.Point{X: 1, Y: 2}},
+	}
+	for _, tt := range tests {
+		ops := append(tt.ops, op("MoveTo", 1, 2), op("LineTo", 3, 4), op("Stroke"))
+		got := shapes(t, ops...)
+		if len(got) != 1 {
+			t.Fatalf("%s: %d shapes, want 1", tt.name, len(got))
+		}
+		if start := got[0].Path.Segments[0].Points[0]; !near(start, tt.want) {
+			t.Errorf("%s: (1, 2) is at %v, want %v", tt.name, start, tt.want)
+		}
+	}
+}
+
+func TestShapesArc(t *testing.T) {
+	got := shapes(t, op("DrawArc", 0, 0, 1, 0, math.Pi/2), op("Stroke"))
+	k := 4.0 / 3.0 * math.Tan(math.Pi/8)
+	want := []Segment{
+		{Kind: SegmentMove, Points: []geom.Point{{X: 1, Y: 0}}},
+		{Kind: SegmentCubic, Points: []geom.Point{{X: 1, Y: k}, {X: k, Y: 1}, {X: 0, Y: 1}}},
+	}
+	segments := got[0].Path.Segments
+	if len(segments) != len(want) {
+		t.Fatalf("quarter arc has %d segments, want %d", len(segments), len(want))
+	}
+	for i, s := range segments {
+		if s.Kind != want[i].Kind || len(s.Points) != len(want[i].Points) {
+			t.Fatalf("segment %d is %v, want %v", i, s, want[i])
+		}
+		for j, p := range s.Points {
+			if !near(p, want[i].Points[j]) {
+				t.Errorf("segment %d point %d is %v, want %v", i, j, p, want[i].Points[j])
+			}
+		}
+	}
+
+	// a circle is four quarter curves, each close to the circle at its middle.
+	got = shapes(t, op("DrawCircle", 0, 0, 1), op("Fill"))
+	segments = got[0].Path.Segments
+	if len(segments) != 6 || segments[5].Kind != SegmentClose {
+		t.Fatalf("circle segments are %v, want a move, four cubics and a close", segments)
+	}
+	from := segments[0].Points[0]
+	for _, s := range segments[1:5] {
+		p := s.Points
+		mid := geom.Point{
+			X: (from.X + 3*p[0].X + 3*p[1].X + p[2].X) / 8,
+			Y: (from.Y + 3*p[0].Y + 3*p[1].Y + p[2].Y) / 8,
+		}
+		if r := math.Hypot(mid.X, mid.Y); math.Abs(r-1) > 1e-3 {
+			t.Errorf("curve from %v has its middle at radius %v", from, r)
+		}
+		from = p[2]
+	}
+}
+
+func TestShapesClip(t *testing.T) {
+	got := shapes(t,
+		op("DrawRectangle", 0, 0, 5, 5), op("Clip"),
+		op("DrawCircle", 2, 2, 1), op("Fill"),
+		op("DrawCircle", 3, 3, 1), op("Fill"),
+		op("DrawRectangle", 1, 1, 5, 5), op("Clip"),
+		op("DrawCircle", 4, 4, 1), op("Fill"),
+		op("ResetClip"),
+		op("DrawCircle", 5, 5, 1), op("Fill"),
+	)
+	if len(got) != 4 {
+		t.Fatalf("%d shapes, want 4", len(got))
+	}
+	if len(got[0].Clip) != 1 || len(got[1].Clip) != 1 || got[0].Clip[0] != got[1].Clip[0] {
+		t.Error("shapes drawn under the same clip do not share it")
+	}
+	if len(got[2].Clip) != 2 || got[2].Clip[0] != got[0].Clip[0] {
+		t.Error("a second clip does not nest inside the first")
+	}
+	if got[3].Clip != nil {
+		t.Error("ResetClip kept the clip")
+	}
+}
+
+func TestShapesClear(t *testing.T) {
+	got := shapes(t,
+		op("DrawCircle", 2, 2, 1), op("Fill"),
+		op("Translate", 3, 3),
+		op("SetRGBA", 1, 0, 0, 1), op("Clear"),
+		op("DrawCircle", 2, 2, 1), op("Fill"),
+	)
+	if len(got) != 2 {
+		t.Fatalf("%d shapes, want the clear and the last circle", len(got))
+	}
+	// the clear covers the whole list, ignoring the transform.
+	want := []geom.Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	for i, p := range want {
+		if got[0].Path.Segments[i].Points[0] != p {
+			t.Errorf("clear point %d is %v, want %v", i, got[0].Path.Segments[i].Points[0], p)
+		}
+	}
+	if got[0].Color.R != 1 || got[0].Stroke {
+		t.Error("clear is not a red fill")
+	}
+	if start := got[1].Path.Segments[0].Points[0]; !near(start, geom.Point{X: 6, Y: 5}) {
+		t.Errorf("circle after clear starts at %v, want (6, 5)", start)
+	}
+}